@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/scheduler"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+)
+
+// runDaemon scrapes every source on its configured interval until SIGTERM or
+// SIGINT cancels ctx, then waits for the runs in progress to finish their
+// inserts.
+func runDaemon(ctx context.Context, cfg *config.Config) error {
+	db, p, sources, err := setUp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	state, err := scheduler.LoadState(cfg.Daemon.StateFile)
+	if err != nil {
+		return fmt.Errorf("error loading daemon state: %w", err)
+	}
+
+	s := scheduler.New(state, cfg.Daemon.Jitter)
+	for _, src := range sources {
+		src := src
+		interval := src.(source.Configured).Config().Interval
+
+		s.Add(scheduler.Job{
+			Name:     src.Name(),
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				report, err := p.run(ctx, []source.Source{src})
+				if err != nil {
+					errorHandler.HandleErrorWithSection(err, "Failed to run source", src.Name())
+					return err
+				}
+				// A source that timed out is run again after a restart
+				if report.Count(source.StatusTimedOut) > 0 {
+					return errors.New("timed out")
+				}
+				return nil
+			},
+		})
+		log.Printf("Scheduled %s every %v\n", src.Name(), interval)
+	}
+
+	s.Start(ctx)
+	log.Println("Daemon stopped")
+	return nil
+}
@@ -1,21 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/joho/godotenv"
 	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/dedupe"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/discord"
-	"github.com/justinemmanuelmercado/go-scraper/pkg/hackernews"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/httpclient"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/keywords"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
-	"github.com/justinemmanuelmercado/go-scraper/pkg/reddit"
-	"github.com/justinemmanuelmercado/go-scraper/pkg/rss_feed"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/salary"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+	_ "github.com/justinemmanuelmercado/go-scraper/pkg/source/all"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/store"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/workplace"
 )
 
 func init() {
@@ -25,92 +35,531 @@ func init() {
 	}
 }
 
-func setUpDatabase() (*pgx.Conn, error) {
-	dbPw := os.Getenv("POSTGRES_PASSWORD")
-	dbUser := os.Getenv("POSTGRES_USER")
-	dbName := os.Getenv("POSTGRES_DB")
-	dbUrl := fmt.Sprintf("postgresql://%s:%s@localhost:5432/%s?sslmode=disable", dbUser, dbPw, dbName)
-	db, err := store.OpenDB(dbUrl)
+// setUpDatabase opens the backend picked by the driver in the config.
+func setUpDatabase(ctx context.Context, cfg config.Database) (*store.Backend, error) {
+	if cfg.Driver == config.DriverSQLite {
+		backend, err := store.OpenSQLite(ctx, cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening database: %v", err)
+		}
+		return backend, nil
+	}
+
+	pool, err := store.OpenDB(ctx, store.PoolConfig{
+		URL:             store.DatabaseURL(),
+		MaxConns:        cfg.MaxConns,
+		MinConns:        cfg.MinConns,
+		MaxConnLifetime: cfg.MaxConnLifetime,
+		MaxConnIdleTime: cfg.MaxConnIdleTime,
+		SSLMode:         cfg.SSLMode,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	return db, nil
+	return store.NewPostgres(pool)
 }
 
-func getRssFeedNotices() ([]*models.Notice, error) {
-	newNotices, err := rss_feed.GetAllNotices()
-	if err != nil {
-		return nil, fmt.Errorf("error getting Notices from RSS feeds: %w", err)
+// notifier announces new notices, on Discord outside of tests.
+type notifier interface {
+	// SendList sends notices, alsoOn are the other sources of each notice
+	// keyed by its id
+	SendList(ctx context.Context, notices []*models.Notice, alsoOn map[string][]string)
+	SendReport(ctx context.Context, summary discord.Summary) error
+}
+
+// updateNotifier is a notifier that can also announce notices that were
+// edited since they were stored.
+type updateNotifier interface {
+	SendUpdates(ctx context.Context, notices []*models.Notice)
+}
+
+// The tests replace these with in-memory fakes.
+var (
+	openBackend = setUpDatabase
+	newNotifier = func() (notifier, error) {
+		client, err := discord.NewClient()
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
 	}
+)
+
+// saveTimeout bounds inserting and announcing what a run fetched. It isn't
+// cut short by the run being cancelled, so nothing fetched is lost.
+const saveTimeout = 5 * time.Minute
 
-	return newNotices, nil
+// pipeline stores and announces what sources find. It is safe to run for
+// several sources at once.
+type pipeline struct {
+	noticeStore  store.NoticeRepository
+	sourceStore  store.SourceRepository
+	keywordStore store.KeywordRepository
+	feedStates   store.FeedStateRepository
+	// keywords tags the inserted notices
+	keywords *keywords.Dictionary
+	// notifier is nil when Discord isn't set up
+	notifier notifier
+	// updates sends the edited notices to a notifier that supports it
+	updates bool
+	// timeout caps the fetching of a run, sources still running after it
+	// are reported as timed out
+	timeout time.Duration
+
+	// statuses holds the status of every source on its last run, so the
+	// daemon reports a failing source once instead of on every run
+	mu       sync.Mutex
+	statuses map[string]source.Status
 }
 
-func getHackerNews() []*models.Notice {
-	newNotices := hackernews.ScrapeCurrentWhoIsHiringPosts()
-	return newNotices
+func newPipeline(db *store.Backend, n notifier, timeout time.Duration, dictionary *keywords.Dictionary, updates bool) *pipeline {
+	return &pipeline{
+		noticeStore:  db.Notices,
+		sourceStore:  db.Sources,
+		keywordStore: db.Keywords,
+		feedStates:   db.FeedStates,
+		keywords:     dictionary,
+		notifier:     n,
+		updates:      updates,
+		timeout:      timeout,
+		statuses:     map[string]source.Status{},
+	}
 }
 
-func scrape() {
+// statusChanged records the status of every source in report and tells
+// whether any of them changed since its last run. A source that didn't run
+// before counts as OK.
+func (p *pipeline) statusChanged(report source.Report) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for _, result := range report.Results {
+		status := result.Status()
+		if status != p.statuses[result.Source] {
+			changed = true
+		}
+		p.statuses[result.Source] = status
+	}
+	return changed
+}
+
+// ensureSources creates the "Source" rows of sources that describe
+// themselves, so their notices don't violate the foreign key.
+func (p *pipeline) ensureSources(ctx context.Context, sources []source.Source) {
+	for _, s := range sources {
+		d, ok := s.(source.Describer)
+		if !ok {
+			continue
+		}
+
+		err := p.sourceStore.EnsureSource(ctx, s.Name(), d.Description(), d.Homepage())
+		errorHandler.HandleErrorWithSection(err, "Failed to create source", s.Name())
+	}
+}
+
+// run fetches sources, inserts their notices and sends the new ones to
+// Discord along with a report of every source, which it returns. Cancelling
+// ctx stops the fetching, but whatever was fetched is still inserted.
+func (p *pipeline) run(ctx context.Context, sources []source.Source) (source.Report, error) {
 	startTime := time.Now()
 
-	db, err := setUpDatabase()
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v\n", err)
+	fetchCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
 	}
 
-	rssFeedNotices, err := getRssFeedNotices()
-	errorHandler.HandleErrorWithSection(err, "Failed to get notices from rss feeds", "RSS Feeds")
+	results := source.Run(fetchCtx, sources)
+	report := source.Report{Results: results}
+	changed := p.statusChanged(report)
 
-	redditNotices, err := reddit.GetNoticesFromPosts()
-	errorHandler.HandleErrorWithSection(err, "Failed to get notices from reddit", "Reddit")
+	var allNotices []*models.Notice
+	var feeds []source.FeedResult
+	for _, result := range results {
+		// Every failure is logged here and only here. The error of a failed
+		// source already joins those of its feeds.
+		switch result.Status() {
+		case source.StatusTimedOut:
+			errorHandler.HandleErrorWithSection(result.Err, "Timed out getting notices", result.Source)
+			continue
+		case source.StatusFailed:
+			errorHandler.HandleErrorWithSection(result.Err, "Failed to get notices", result.Source)
+			continue
+		}
+		for _, feed := range result.FailedFeeds() {
+			errorHandler.HandleErrorWithSection(feed.Err, "Failed to get feed", feed.Source)
+		}
+		log.Printf("Got %d notices from %s in %v (%s), %d of %d feeds failed, %d retries, %d feeds not modified\n", len(result.Notices), result.Source, result.Duration, result.Status(), len(result.FailedFeeds()), len(result.Feeds), result.Retries, result.NotModified())
+		allNotices = append(allNotices, result.Notices...)
+		feeds = append(feeds, result.Feeds...)
+	}
+	log.Printf("Fetched sources: %s\n", report.Summary())
 
-	hnNotices := getHackerNews()
+	extractSalaries(allNotices)
+	classifyWorkplaces(allNotices)
+	fingerprint(allNotices)
 
-	allNotices := append(rssFeedNotices, redditNotices...)
-	allNotices = append(allNotices, hnNotices...)
 	log.Printf("Trying to insert %d notices \n", len(allNotices))
 
-	noticeStore := store.InitNotice(db)
-	oldNoticeCount := noticeStore.GetCount()
-	err = noticeStore.CreateNotices(allNotices)
-	if err != nil {
-		log.Fatalf("Error inserting notices: %v\n", err)
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+	defer cancel()
+
+	summary := discord.Summary{Sources: report}
+	inserted, err := p.noticeStore.CreateNotices(saveCtx, allNotices)
+	var insertErr *store.InsertError
+	var failedRows []*store.RowError
+	if errors.As(err, &insertErr) {
+		for _, row := range insertErr.Rows {
+			errorHandler.HandleErrorWithSection(row, "Failed to insert notice", row.Notice.SourceID)
+		}
+		failedRows = insertErr.Rows
+		summary.Failed = len(insertErr.Rows)
+	} else if err != nil {
+		return report, fmt.Errorf("error inserting notices: %w", err)
 	}
+	p.saveFeedStates(saveCtx, feeds, failedRows)
+
+	updated := p.updateNotices(saveCtx, stored(allNotices, inserted, failedRows))
 
-	newNoticeCount := noticeStore.GetCount()
-	noticesInserted := newNoticeCount - oldNoticeCount
+	p.tagNotices(saveCtx, inserted, updated)
+	summary.Duplicates = p.linkDuplicates(saveCtx, inserted)
 
-	if noticesInserted == 0 {
+	// A run where a source started failing or recovered is reported even
+	// though it found nothing, so it doesn't go unnoticed. Repeated failures
+	// aren't reported again.
+	sendUpdates := p.updates && len(updated) > 0
+	if len(inserted) == 0 {
 		log.Println("No new notices inserted")
-		return
+		if !changed && !sendUpdates {
+			return report, nil
+		}
+	} else {
+		log.Printf("Inserted %d new notices, %d failed\n", len(inserted), summary.Failed)
 	}
 
-	latestPosts := noticeStore.GetLatest(noticesInserted)
-
-	bot, dscErr := discord.InitDiscordClient()
+	summary.Scraped = len(allNotices)
+	summary.New = len(inserted)
+	summary.Updated = len(updated)
+	summary.Runtime = time.Since(startTime)
 
-	if dscErr == nil {
-		discord.SendList(bot, latestPosts)
-		err = discord.SendSuccessNotificatioin(bot, len(allNotices), noticesInserted, time.Since(startTime))
-		errorHandler.HandleErrorWithSection(err, "Failed to send success notification", "Discord")
+	if p.notifier != nil {
+		// A job found on several boards is only sent once
+		notices, alsoOn := dedupe.AlsoOn(inserted)
+		p.notifier.SendList(saveCtx, notices, alsoOn)
+		if u, ok := p.notifier.(updateNotifier); ok && sendUpdates {
+			notices, _ := dedupe.AlsoOn(updated)
+			u.SendUpdates(saveCtx, notices)
+		}
+		err = p.notifier.SendReport(saveCtx, summary)
+		errorHandler.HandleErrorWithSection(err, "Failed to send the run report", "Discord")
 	} else {
 		log.Println("Discord client not initialized")
 	}
 
-	log.Println("Script run successfully")
+	return report, nil
+}
+
+// saveFeedStates saves the validators of feeds once their notices are
+// stored. The feeds of a source with a notice that failed to insert are left
+// as they were, so they are downloaded in full and the notice is tried
+// again.
+func (p *pipeline) saveFeedStates(ctx context.Context, feeds []source.FeedResult, failed []*store.RowError) {
+	if p.feedStates == nil {
+		return
+	}
+
+	retry := map[string]bool{}
+	for _, row := range failed {
+		retry[row.Notice.SourceID] = true
+	}
+	for _, feed := range feeds {
+		if feed.State == nil || retry[feed.Source] {
+			continue
+		}
+		err := p.feedStates.SaveFeedState(ctx, feed.State)
+		errorHandler.HandleErrorWithSection(err, "Failed to save feed state of "+feed.URL, feed.Source)
+	}
+}
 
+// stored returns the notices that were neither inserted nor failed to, which
+// means they were already stored.
+func stored(notices []*models.Notice, inserted []*models.Notice, failed []*store.RowError) []*models.Notice {
+	skip := map[*models.Notice]bool{}
+	for _, notice := range inserted {
+		skip[notice] = true
+	}
+	for _, row := range failed {
+		skip[row.Notice] = true
+	}
+
+	var rest []*models.Notice
+	for _, notice := range notices {
+		if !skip[notice] {
+			rest = append(rest, notice)
+		}
+	}
+	return rest
+}
+
+// updateNotices saves the edits of notices that were already stored and
+// returns the ones that changed. Notices that fail to update are logged and
+// left as they were.
+func (p *pipeline) updateNotices(ctx context.Context, notices []*models.Notice) []*models.Notice {
+	if len(notices) == 0 {
+		return nil
+	}
+
+	updated, err := p.noticeStore.UpdateNotices(ctx, notices)
+	var updateErr *store.InsertError
+	if errors.As(err, &updateErr) {
+		for _, row := range updateErr.Rows {
+			errorHandler.HandleErrorWithSection(row, "Failed to update notice", row.Notice.SourceID)
+		}
+	} else if err != nil {
+		errorHandler.HandleErrorWithSection(err, "Failed to update notices", "Database")
+	}
+
+	log.Printf("Updated %d of %d notices that were already stored\n", len(updated), len(notices))
+	return updated
+}
+
+// extractSalaries parses the salary of every notice that mentions one.
+func extractSalaries(notices []*models.Notice) {
+	found := 0
+	for _, notice := range notices {
+		if salary.Extract(notice) {
+			found++
+		}
+	}
+	log.Printf("Found a salary in %d of %d notices\n", found, len(notices))
+}
+
+// classifyWorkplaces sets where the work of every notice happens.
+func classifyWorkplaces(notices []*models.Notice) {
+	kinds := map[workplace.Kind]int{}
+	for _, notice := range notices {
+		w := workplace.Classify(notice)
+		w.Apply(notice)
+		kinds[w.Kind]++
+	}
+	log.Printf("Classified %d notices: %d remote, %d remote in a region, %d hybrid, %d on-site, %d unknown\n", len(notices), kinds[workplace.RemoteGlobal], kinds[workplace.RemoteRegion], kinds[workplace.Hybrid], kinds[workplace.Onsite], kinds[""])
+}
+
+// fingerprint sets what notices are compared on to find duplicates.
+func fingerprint(notices []*models.Notice) {
+	for _, notice := range notices {
+		dedupe.Of(notice).Apply(notice)
+	}
+}
+
+// linkDuplicates marks the inserted notices that are the same job as one
+// stored before them, or earlier in notices, and returns how many were. The
+// duplicates are still stored, pointing at their canonical notice.
+func (p *pipeline) linkDuplicates(ctx context.Context, notices []*models.Notice) int {
+	position := map[string]int{}
+	for i, notice := range notices {
+		position[notice.ID] = i
+	}
+
+	since := time.Now().Add(-dedupe.Window)
+	found := 0
+	for i, notice := range notices {
+		f := dedupe.Of(notice)
+		candidates, err := p.noticeStore.GetDuplicateCandidates(ctx, since, f.Title, f.BodyHash())
+		if err != nil {
+			errorHandler.HandleErrorWithSection(err, "Failed to look up the duplicates of notice "+notice.ID, notice.SourceID)
+			continue
+		}
+
+		// The notices inserted along with this one only count when they came
+		// before it, and the ones stored earlier are preferred
+		var earlier, batch []*models.Notice
+		for _, candidate := range candidates {
+			j, inserted := position[candidate.ID]
+			switch {
+			case !inserted:
+				earlier = append(earlier, candidate)
+			case j < i:
+				batch = append(batch, candidate)
+			}
+		}
+		slices.SortFunc(batch, func(a, b *models.Notice) int {
+			return position[a.ID] - position[b.ID]
+		})
+
+		canonical := dedupe.NewIndex(append(earlier, batch...)).Find(notice)
+		if canonical == nil {
+			continue
+		}
+
+		err = p.noticeStore.SetCanonical(ctx, notice.ID, canonical.ID)
+		if err != nil {
+			errorHandler.HandleErrorWithSection(err, "Failed to mark notice "+notice.ID+" as a duplicate", notice.SourceID)
+			continue
+		}
+		notice.CanonicalID = &canonical.ID
+		found++
+	}
+	if len(notices) > 0 {
+		log.Printf("Found %d duplicates in %d new notices\n", found, len(notices))
+	}
+	return found
+}
+
+// tagNotices links every notice to the keywords it mentions. The keywords of
+// edited notices are replaced, so they lose the ones they no longer mention.
+// A notice that can't be tagged is logged and left as it was.
+func (p *pipeline) tagNotices(ctx context.Context, inserted, edited []*models.Notice) {
+	notices := slices.Concat(inserted, edited)
+	matches := make([][]string, len(notices))
+	var values []string
+	seen := map[string]bool{}
+	for i, notice := range notices {
+		matches[i] = p.keywords.Match(notice)
+		for _, value := range matches[i] {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	if len(values) == 0 && len(edited) == 0 {
+		return
+	}
+
+	ids := map[string]string{}
+	if len(values) > 0 {
+		stored, err := p.keywordStore.UpsertKeywords(ctx, values)
+		if err != nil {
+			errorHandler.HandleErrorWithSection(err, "Failed to save keywords", "Database")
+			return
+		}
+		for _, keyword := range stored {
+			ids[keyword.Value] = keyword.ID
+		}
+	}
+
+	tagged := 0
+	for i, notice := range notices {
+		isEdited := i >= len(inserted)
+		if len(matches[i]) == 0 && !isEdited {
+			continue
+		}
+		keywordIDs := make([]string, len(matches[i]))
+		for j, value := range matches[i] {
+			keywordIDs[j] = ids[value]
+		}
+
+		link := p.keywordStore.LinkKeywords
+		if isEdited {
+			link = p.keywordStore.SetKeywords
+		}
+		if err := link(ctx, notice.ID, keywordIDs); err != nil {
+			errorHandler.HandleErrorWithSection(err, "Failed to tag notice "+notice.ID, notice.SourceID)
+			continue
+		}
+		if len(keywordIDs) > 0 {
+			tagged++
+		}
+	}
+	log.Printf("Tagged %d of %d notices with %d keywords\n", tagged, len(notices), len(values))
+}
+
+func loadConfig(path string) *config.Config {
+	if path == "" {
+		path = os.Getenv("SCRAPER_CONFIG")
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		log.Fatalf("Invalid config: %v\n", err)
+	}
+
+	return cfg
+}
+
+// setUp connects to the database and Discord and builds the configured
+// sources. It refuses to run on a database that isn't migrated. The caller
+// closes the database unless an error is returned.
+func setUp(ctx context.Context, cfg *config.Config) (*store.Backend, *pipeline, []source.Source, error) {
+	db, err := openBackend(ctx, cfg.Database)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+	if err := checkMigrations(ctx, db); err != nil {
+		db.Close()
+		return nil, nil, nil, err
+	}
+
+	n, err := newNotifier()
+	if err != nil {
+		errorHandler.HandleErrorWithSection(err, "Failed to set up the Discord client", "Discord")
+		n = nil
+	}
+
+	p := newPipeline(db, n, cfg.Timeout, keywords.New(cfg.Keywords), cfg.Notify.Updates)
+	deps := source.Deps{
+		Known:      p.noticeStore.GetGuids,
+		Recent:     p.noticeStore.GetRecentGuids,
+		FeedStates: db.FeedStates,
+		HTTPClient: httpclient.New(cfg.HTTP),
+	}
+	sources, err := source.Build(cfg, deps)
+	if err != nil {
+		db.Close()
+		return nil, nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+	p.ensureSources(ctx, sources)
+
+	return db, p, sources, nil
+}
+
+func scrape(ctx context.Context, cfg *config.Config) error {
+	db, p, sources, err := setUp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := p.run(ctx, sources); err != nil {
+		return err
+	}
+
+	log.Println("Script run successfully")
+	return nil
 }
 
 func main() {
 	// Define a flag
 	genMarkdown := flag.Bool("markdown", false, "Generate Markdown file for latest notices")
+	configPath := flag.String("config", "", "Path to the sources config file, defaults to $SCRAPER_CONFIG or the built in sources")
+	daemon := flag.Bool("daemon", false, "Keep running and scrape each source on its own interval")
 	flag.Parse()
 
-	if *genMarkdown {
-		GenerateMarkdown()
+	cfg := loadConfig(*configPath)
+
+	// SIGTERM or SIGINT stops the fetching, what was fetched is still saved
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	if flag.Arg(0) == "migrate" {
+		err = runMigrate(ctx, cfg, flag.Args()[1:])
+	} else if *genMarkdown {
+		GenerateMarkdown(ctx, cfg)
+	} else if *daemon {
+		err = runDaemon(ctx, cfg)
 	} else {
-		scrape()
+		err = scrape(ctx, cfg)
+	}
+
+	// Exiting skips deferred calls, so the commands return their errors and
+	// close what they opened first
+	if err != nil {
+		stop()
+		log.Fatalf("%v\n", err)
 	}
 }
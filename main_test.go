@@ -0,0 +1,670 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/discord"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/store"
+)
+
+// fakeBoards holds what each fake source returns, by source name. Tests
+// change it between runs.
+var (
+	fakeMu     sync.Mutex
+	fakeBoards = map[string]fakeBoard{}
+)
+
+type fakeBoard struct {
+	notices []models.Notice
+	err     error
+	// hang makes the source wait until it is cancelled
+	hang bool
+	// refetch returns the notices that are already stored too, like an RSS
+	// feed does
+	refetch bool
+}
+
+// fakeSource stands in for the scrapers. Like them it skips the guids that
+// are already stored.
+type fakeSource struct {
+	name  string
+	known func(context.Context, string) (map[string]bool, error)
+}
+
+func init() {
+	source.Register("fake", func(cfg config.Source, deps source.Deps) (source.Source, error) {
+		return &fakeSource{name: cfg.Name, known: deps.Known}, nil
+	})
+}
+
+func (f *fakeSource) Name() string {
+	return f.name
+}
+
+func (f *fakeSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	fakeMu.Lock()
+	board := fakeBoards[f.name]
+	fakeMu.Unlock()
+
+	if board.err != nil {
+		return nil, board.err
+	}
+	if board.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	known, err := f.known(ctx, f.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var notices []*models.Notice
+	for _, n := range board.notices {
+		if known[n.Guid] && !board.refetch {
+			continue
+		}
+		notice := n
+		notices = append(notices, &notice)
+	}
+	return notices, nil
+}
+
+func setFakeBoards(t *testing.T, boards map[string]fakeBoard) {
+	t.Helper()
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	fakeBoards = boards
+}
+
+type summary struct {
+	scraped, inserted, failed, duplicates, updated int
+	status                                         source.Status
+}
+
+// fakeNotifier records what would have been sent to Discord.
+type fakeNotifier struct {
+	sent      [][]*models.Notice
+	alsoOn    []map[string][]string
+	updates   [][]*models.Notice
+	summaries []summary
+}
+
+func (f *fakeNotifier) SendList(ctx context.Context, notices []*models.Notice, alsoOn map[string][]string) {
+	f.sent = append(f.sent, notices)
+	f.alsoOn = append(f.alsoOn, alsoOn)
+}
+
+func (f *fakeNotifier) SendUpdates(ctx context.Context, notices []*models.Notice) {
+	f.updates = append(f.updates, notices)
+}
+
+func (f *fakeNotifier) SendReport(ctx context.Context, s discord.Summary) error {
+	f.summaries = append(f.summaries, summary{s.Scraped, s.New, s.Failed, s.Duplicates, s.Updated, s.Sources.Status()})
+	return nil
+}
+
+// useFakes makes setUp use backend and notifier. A nil notifier behaves as
+// if Discord failed to set up.
+func useFakes(t *testing.T, backend *store.Backend, n *fakeNotifier) {
+	t.Helper()
+	oldBackend, oldNotifier := openBackend, newNotifier
+
+	openBackend = func(ctx context.Context, cfg config.Database) (*store.Backend, error) {
+		return backend, nil
+	}
+	newNotifier = func() (notifier, error) {
+		if n == nil {
+			return nil, errors.New("no token")
+		}
+		return n, nil
+	}
+
+	t.Cleanup(func() {
+		openBackend, newNotifier = oldBackend, oldNotifier
+	})
+}
+
+const fakeConfig = `
+sources:
+  - name: HackerNews
+    type: fake
+    description: Who is hiring
+  - name: WeWorkRemotely
+    type: fake
+    filters:
+      exclude: [senior]
+  - name: Reddit
+    type: fake
+`
+
+func fakeNotice(sourceId string, guid string, title string) models.Notice {
+	return models.Notice{
+		ID:         sourceId + "-" + guid,
+		Title:      title,
+		Body:       "<p>" + title + "</p>",
+		URL:        "https://example.com/" + guid,
+		AuthorName: sourceId,
+		AuthorURL:  "https://example.com",
+		SourceID:   sourceId,
+		Raw:        "{}",
+		Guid:       guid,
+	}
+}
+
+func titles(notices []*models.Notice) map[string]bool {
+	set := map[string]bool{}
+	for _, n := range notices {
+		set[n.Title] = true
+	}
+	return set
+}
+
+func TestScrape(t *testing.T) {
+	cfg, err := config.Parse([]byte(fakeConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	backend := store.NewMemory()
+	n := &fakeNotifier{}
+	useFakes(t, backend, n)
+
+	hackerNews := []models.Notice{
+		fakeNotice("HackerNews", "1", "Backend Engineer at Acme"),
+		fakeNotice("HackerNews", "2", "Designer at Widgets"),
+	}
+	setFakeBoards(t, map[string]fakeBoard{
+		"HackerNews": {notices: hackerNews},
+		"WeWorkRemotely": {notices: []models.Notice{
+			fakeNotice("WeWorkRemotely", "a", "Go Developer"),
+			fakeNotice("WeWorkRemotely", "b", "Senior Go Developer"),
+			// Also posted on Hacker News, a different source so it's stored
+			// but only sent once
+			fakeNotice("WeWorkRemotely", "1", "Backend Engineer at Acme"),
+		}},
+		"Reddit": {err: errors.New("reddit is down")},
+	})
+
+	scrape(context.Background(), cfg)
+
+	// The failing source doesn't stop the others, the filter drops the
+	// senior role
+	if len(n.sent) != 1 || len(n.sent[0]) != 3 {
+		t.Fatalf("Expected one list of 3 notices, got %v", n.sent)
+	}
+	sent := titles(n.sent[0])
+	if sent["Senior Go Developer"] || !sent["Go Developer"] || !sent["Designer at Widgets"] || !sent["Backend Engineer at Acme"] {
+		t.Errorf("Unexpected notices sent %v", sent)
+	}
+	if also := n.alsoOn[0]["HackerNews-1"]; len(also) != 1 || also[0] != "WeWorkRemotely" {
+		t.Errorf("Expected Backend Engineer at Acme to be also on WeWorkRemotely, got %v", n.alsoOn[0])
+	}
+	if n.summaries[0] != (summary{scraped: 4, inserted: 4, duplicates: 1, status: source.StatusPartial}) {
+		t.Errorf("Unexpected summary %+v", n.summaries[0])
+	}
+
+	// New notices are tagged with the keywords they mention
+	tags, err := backend.Keywords.GetNoticeKeywords(context.Background(), "WeWorkRemotely-a")
+	if err != nil || len(tags) != 1 || tags[0].Value != "Go" {
+		t.Errorf("Expected Go Developer to be tagged with Go, got %v: %v", tags, err)
+	}
+	tags, err = backend.Keywords.GetNoticeKeywords(context.Background(), "HackerNews-2")
+	if err != nil || len(tags) != 0 {
+		t.Errorf("Expected Designer at Widgets to have no keywords, got %v: %v", tags, err)
+	}
+
+	row, err := backend.Sources.GetSourceByName(context.Background(), "HackerNews")
+	if err != nil || *row.Description != "Who is hiring" {
+		t.Errorf("Expected the source row to be created from the config, got %+v: %v", row, err)
+	}
+
+	// Only what's new is sent on the next run, even if a scraper returns a
+	// notice it has seen before
+	setFakeBoards(t, map[string]fakeBoard{
+		"HackerNews": {notices: append(hackerNews, fakeNotice("HackerNews", "3", "SRE at Quill"))},
+		"WeWorkRemotely": {notices: []models.Notice{
+			fakeNotice("WeWorkRemotely", "a", "Go Developer"),
+		}},
+		"Reddit": {notices: []models.Notice{
+			fakeNotice("Reddit", "r1", "[Hiring] Rust developer"),
+		}},
+	})
+
+	scrape(context.Background(), cfg)
+
+	if len(n.sent) != 2 || len(n.sent[1]) != 2 {
+		t.Fatalf("Expected a second list of 2 notices, got %v", n.sent)
+	}
+	sent = titles(n.sent[1])
+	if !sent["SRE at Quill"] || !sent["[Hiring] Rust developer"] {
+		t.Errorf("Unexpected notices sent %v", sent)
+	}
+	if n.summaries[1] != (summary{scraped: 2, inserted: 2}) {
+		t.Errorf("Unexpected summary %+v", n.summaries[1])
+	}
+
+	// Nothing new, nothing sent
+	scrape(context.Background(), cfg)
+	if len(n.sent) != 2 || len(n.summaries) != 2 {
+		t.Errorf("Expected nothing to be sent, got %d lists", len(n.sent))
+	}
+
+	notices, err := backend.Notices.GetLatestNotices(context.Background())
+	if err != nil || len(notices) != 5 {
+		t.Errorf("Expected 5 stored notices without Reddit, got %d: %v", len(notices), err)
+	}
+}
+
+func TestScrapeUpdates(t *testing.T) {
+	backend := store.NewMemory()
+	n := &fakeNotifier{}
+	useFakes(t, backend, n)
+
+	original := fakeNotice("WeWorkRemotely", "a", "Go Developer")
+	setFakeBoards(t, map[string]fakeBoard{
+		"WeWorkRemotely": {notices: []models.Notice{original}, refetch: true},
+	})
+
+	for _, notify := range []bool{false, true} {
+		cfg, err := config.Parse([]byte(fakeConfig))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		cfg.Notify.Updates = notify
+		scrape(context.Background(), cfg)
+	}
+
+	// Found again unchanged, so nothing was updated or sent after the first run
+	if len(n.sent) != 1 || len(n.updates) != 0 || len(n.summaries) != 1 {
+		t.Fatalf("Expected only the first run to send, got %d lists, %d updates", len(n.sent), len(n.updates))
+	}
+
+	edited := original
+	edited.Body = "<p>Go Developer, now paying more</p>"
+	setFakeBoards(t, map[string]fakeBoard{
+		"WeWorkRemotely": {notices: []models.Notice{edited}, refetch: true},
+	})
+
+	cfg, err := config.Parse([]byte(fakeConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scrape(context.Background(), cfg)
+	if len(n.updates) != 0 || len(n.summaries) != 1 {
+		t.Errorf("Expected the update not to be sent without opting in, got %d updates", len(n.updates))
+	}
+
+	revisions, err := backend.Notices.GetRevisions(context.Background(), original.ID)
+	if err != nil || len(revisions) != 1 || revisions[0].Body != original.Body {
+		t.Fatalf("Expected the original body as a revision, got %v: %v", revisions, err)
+	}
+
+	edited.Body = "<p>Go Developer, now paying even more</p>"
+	setFakeBoards(t, map[string]fakeBoard{
+		"WeWorkRemotely": {notices: []models.Notice{edited}, refetch: true},
+	})
+	cfg.Notify.Updates = true
+	scrape(context.Background(), cfg)
+
+	if len(n.updates) != 1 || len(n.updates[0]) != 1 || n.updates[0][0].ID != original.ID || n.updates[0][0].Body != edited.Body {
+		t.Fatalf("Expected the edited notice to be sent as an update, got %v", n.updates)
+	}
+	if len(n.sent) != 2 || len(n.sent[1]) != 0 {
+		t.Errorf("Expected no new notices to be sent, got %v", n.sent)
+	}
+	if n.summaries[1] != (summary{scraped: 1, updated: 1}) {
+		t.Errorf("Unexpected summary %+v", n.summaries[1])
+	}
+
+	notices, err := backend.Notices.GetLatestNotices(context.Background())
+	if err != nil || len(notices) != 1 || notices[0].Body != edited.Body || notices[0].UpdatedAt == nil {
+		t.Errorf("Expected the stored notice to be updated, got %v: %v", notices, err)
+	}
+
+	// An edit replaces the keywords of the notice
+	edited.Title = "Rust Developer"
+	edited.Body = "<p>Rust Developer</p>"
+	setFakeBoards(t, map[string]fakeBoard{
+		"WeWorkRemotely": {notices: []models.Notice{edited}, refetch: true},
+	})
+	scrape(context.Background(), cfg)
+
+	tags, err := backend.Keywords.GetNoticeKeywords(context.Background(), original.ID)
+	if err != nil || len(tags) != 1 || tags[0].Value != "Rust" {
+		t.Errorf("Expected the notice to be tagged with Rust only, got %v: %v", tags, err)
+	}
+}
+
+func TestScrapeInsertErrors(t *testing.T) {
+	cfg, err := config.Parse([]byte(fakeConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	backend := store.NewMemory()
+	n := &fakeNotifier{}
+	useFakes(t, backend, n)
+
+	// A notice that claims another source can't be inserted, the rest can
+	setFakeBoards(t, map[string]fakeBoard{
+		"HackerNews": {notices: []models.Notice{
+			fakeNotice("HackerNews", "1", "Backend Engineer at Acme"),
+			fakeNotice("Nowhere", "2", "Lost notice"),
+		}},
+	})
+
+	scrape(context.Background(), cfg)
+
+	if len(n.sent) != 1 || len(n.sent[0]) != 1 || n.sent[0][0].Title != "Backend Engineer at Acme" {
+		t.Fatalf("Expected only the valid notice to be sent, got %v", n.sent)
+	}
+	if n.summaries[0] != (summary{scraped: 2, inserted: 1, failed: 1}) {
+		t.Errorf("Unexpected summary %+v", n.summaries[0])
+	}
+}
+
+// failingNotices fails to insert any notice while fail is set.
+type failingNotices struct {
+	store.NoticeRepository
+	fail bool
+}
+
+func (f *failingNotices) CreateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	if f.fail {
+		return nil, errors.New("database is down")
+	}
+	return f.NoticeRepository.CreateNotices(ctx, notices)
+}
+
+func TestScrapeFeedStates(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Jobs</title>
+<item><title>Go Developer</title><link>https://example.com/go</link><guid>go</guid><description>Go</description></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	cfg, err := config.Parse([]byte(`
+sources:
+  - name: Jobs
+    type: rss
+    urls: [` + server.URL + `]
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	backend := store.NewMemory()
+	notices := &failingNotices{NoticeRepository: backend.Notices, fail: true}
+	backend.Notices = notices
+	useFakes(t, backend, nil)
+	_, p, sources, err := setUp(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The insert fails, so the feed is downloaded in full again
+	if _, err := p.run(context.Background(), sources); err == nil {
+		t.Fatal("Expected the insert to fail")
+	}
+	notices.fail = false
+	for range 2 {
+		if _, err := p.run(context.Background(), sources); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := []string{"", "", `"v1"`}
+	if strings.Join(conditional, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected If-None-Match %q, got %q", expected, conditional)
+	}
+	guids, err := backend.Notices.GetGuids(context.Background(), "Jobs")
+	if err != nil || !guids["go"] {
+		t.Errorf("Expected the notice to be stored, got %v: %v", guids, err)
+	}
+}
+
+func TestScrapeLogsFeedFailuresOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Jobs</title>
+<item><title>Go Developer</title><link>https://example.com/go</link><guid>go</guid><description>Go</description></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	// A partial source and a failed one
+	cfg, err := config.Parse([]byte(`
+sources:
+  - name: Jobs
+    type: rss
+    urls: [` + server.URL + `/jobs, ` + server.URL + `/moved]
+  - name: Gone
+    type: rss
+    urls: [` + server.URL + `/gone]
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	useFakes(t, store.NewMemory(), nil)
+	_, p, sources, err := setUp(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := p.run(context.Background(), sources); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, url := range []string{server.URL + "/moved", server.URL + "/gone"} {
+		if count := strings.Count(logs.String(), url); count != 1 {
+			t.Errorf("Expected %s to be logged once, got %d times in:\n%s", url, count, logs.String())
+		}
+	}
+}
+
+func TestScrapeTimeout(t *testing.T) {
+	cfg, err := config.Parse([]byte(fakeConfig + `
+    timeout: 50ms
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	backend := store.NewMemory()
+	n := &fakeNotifier{}
+	useFakes(t, backend, n)
+
+	// Reddit hangs past its timeout, the others are still saved
+	setFakeBoards(t, map[string]fakeBoard{
+		"HackerNews":     {notices: []models.Notice{fakeNotice("HackerNews", "1", "Backend Engineer at Acme")}},
+		"WeWorkRemotely": {notices: []models.Notice{fakeNotice("WeWorkRemotely", "a", "Go Developer")}},
+		"Reddit":         {hang: true},
+	})
+
+	scrape(context.Background(), cfg)
+
+	if len(n.sent) != 1 || len(n.sent[0]) != 2 {
+		t.Fatalf("Expected one list of 2 notices, got %v", n.sent)
+	}
+	if n.summaries[0].status != source.StatusPartial {
+		t.Errorf("Expected the run to be partially failed, got %s", n.summaries[0].status)
+	}
+}
+
+func TestScrapeFailure(t *testing.T) {
+	cfg, err := config.Parse([]byte(fakeConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	backend := store.NewMemory()
+	n := &fakeNotifier{}
+	useFakes(t, backend, n)
+
+	// Nothing new is found, but the failure is still reported
+	setFakeBoards(t, map[string]fakeBoard{
+		"HackerNews":     {err: errors.New("firebase is down")},
+		"WeWorkRemotely": {err: errors.New("feed is down")},
+		"Reddit":         {err: errors.New("reddit is down")},
+	})
+
+	scrape(context.Background(), cfg)
+
+	if len(n.summaries) != 1 || n.summaries[0] != (summary{status: source.StatusFailed}) {
+		t.Fatalf("Expected a failed run to be reported, got %+v", n.summaries)
+	}
+	if len(n.sent) != 1 || len(n.sent[0]) != 0 {
+		t.Errorf("Expected no notices to be sent, got %v", n.sent)
+	}
+}
+
+func TestRunReportsStatusChanges(t *testing.T) {
+	cfg, err := config.Parse([]byte(fakeConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	n := &fakeNotifier{}
+	useFakes(t, store.NewMemory(), n)
+	_, p, sources, err := setUp(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Like the daemon, every source runs on its own
+	var reddit []source.Source
+	for _, s := range sources {
+		if s.Name() == "Reddit" {
+			reddit = append(reddit, s)
+		}
+	}
+
+	// An outage is reported when it starts and when it ends, not on every
+	// run in between
+	runs := []struct {
+		err      error
+		expected []source.Status
+	}{
+		{errors.New("reddit is down"), []source.Status{source.StatusFailed}},
+		{errors.New("reddit is down"), []source.Status{source.StatusFailed}},
+		{nil, []source.Status{source.StatusFailed, source.StatusOK}},
+		{nil, []source.Status{source.StatusFailed, source.StatusOK}},
+	}
+	for i, run := range runs {
+		setFakeBoards(t, map[string]fakeBoard{"Reddit": {err: run.err}})
+		if _, err := p.run(context.Background(), reddit); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var got []source.Status
+		for _, s := range n.summaries {
+			got = append(got, s.status)
+		}
+		if !slices.Equal(got, run.expected) {
+			t.Errorf("Expected the reports %v after run %d, got %v", run.expected, i+1, got)
+		}
+	}
+}
+
+func TestScrapeWithoutDiscord(t *testing.T) {
+	cfg, err := config.Parse([]byte(fakeConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	backend := store.NewMemory()
+	useFakes(t, backend, nil)
+	setFakeBoards(t, map[string]fakeBoard{
+		"HackerNews": {notices: []models.Notice{fakeNotice("HackerNews", "1", "Backend Engineer at Acme ($120k-$150k, Remote US only)")}},
+	})
+
+	scrape(context.Background(), cfg)
+
+	guids, err := backend.Notices.GetGuids(context.Background(), "HackerNews")
+	if err != nil || !guids["1"] {
+		t.Errorf("Expected notices to be stored without Discord, got %v: %v", guids, err)
+	}
+
+	// The salary is parsed before the notice is stored
+	notices, err := backend.Notices.GetLatestNotices(context.Background())
+	if err != nil || len(notices) != 1 {
+		t.Fatalf("Expected 1 notice, got %d: %v", len(notices), err)
+	}
+	if n := notices[0]; n.SalaryAnnualMin == nil || *n.SalaryAnnualMin != 120000 || *n.SalaryCurrency != "USD" {
+		t.Errorf("Expected the salary to be stored, got %+v", n)
+	}
+	if n := notices[0]; n.Workplace == nil || *n.Workplace != "remote-region" || n.Regions == nil || *n.Regions != "US" {
+		t.Errorf("Expected the workplace to be stored, got %v %v", n.Workplace, n.Regions)
+	}
+}
+
+// pendingMigrations has applied the first of its migrations only.
+type pendingMigrations struct {
+	store.Migrations
+}
+
+func (pendingMigrations) Status(ctx context.Context) ([]store.MigrationStatus, error) {
+	applied := time.Now()
+	return []store.MigrationStatus{
+		{Migration: store.Migration{Version: 1, Name: "init"}, AppliedAt: &applied},
+		{Migration: store.Migration{Version: 2, Name: "notice_details"}},
+	}, nil
+}
+
+func TestCheckMigrations(t *testing.T) {
+	backend := store.NewMemory()
+	if err := checkMigrations(context.Background(), backend); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	backend.Migrations = pendingMigrations{}
+	err := checkMigrations(context.Background(), backend)
+	if err == nil || !strings.Contains(err.Error(), "0002_notice_details") || strings.Contains(err.Error(), "0001_init") {
+		t.Errorf("Expected only the pending migration to be reported, got %v", err)
+	}
+
+	// The commands return their errors to main instead of exiting, which
+	// would skip closing the database
+	cfg, err := config.Parse([]byte(fakeConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	useFakes(t, backend, nil)
+	if err := scrape(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "pending migrations") {
+		t.Errorf("Expected scrape to refuse the database, got %v", err)
+	}
+	if err := runMigrate(context.Background(), cfg, []string{"sideways"}); err == nil || err.Error() != migrateUsage {
+		t.Errorf("Expected the usage, got %v", err)
+	}
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"html"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/dlclark/regexp2"
-	"github.com/justinemmanuelmercado/go-scraper/pkg/store"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/dedupe"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/salary"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/workplace"
 )
 
 func printToHTML(text string) string {
@@ -30,22 +35,29 @@ func printToHTMLAndTruncate(text string, length int) string {
 	return finalText
 }
 
-func GenerateMarkdown() {
+func GenerateMarkdown(ctx context.Context, cfg *config.Config) {
 	// Initialize DB connection and NoticeStore
-	db, err := setUpDatabase()
+	db, err := openBackend(ctx, cfg.Database)
 	if err != nil {
 		fmt.Printf("error connecting to database: %v", err)
 		return
 	}
-	store := store.InitNotice(db)
+	defer db.Close()
+	if err := checkMigrations(ctx, db); err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	// Fetch the latest notices
-	notices, err := store.GetLatestNotices()
+	notices, err := db.Notices.GetLatestNotices(ctx)
 	if err != nil {
 		fmt.Println("Error fetching notices:", err)
 		return
 	}
 
+	// A job found on several boards is listed once
+	notices, alsoOn := dedupe.AlsoOn(notices)
+
 	// Create the folder if it doesn't exist
 	if _, err := os.Stat("latest_notices"); os.IsNotExist(err) {
 		os.Mkdir("latest_notices", 0755)
@@ -72,6 +84,15 @@ func GenerateMarkdown() {
 
 		f.WriteString(fmt.Sprintf("## **%s**\n\n", printToHTMLAndTruncate(notice.Title, 80)))
 		f.WriteString(fmt.Sprintf("**From**: %s\n\n", notice.SourceID))
+		if sources := alsoOn[notice.ID]; len(sources) > 0 {
+			f.WriteString(fmt.Sprintf("**Also on**: %s\n\n", strings.Join(sources, ", ")))
+		}
+		if s, ok := salary.FromNotice(notice); ok {
+			f.WriteString(fmt.Sprintf("**Salary**: %s\n\n", s))
+		}
+		if w, ok := workplace.FromNotice(notice); ok {
+			f.WriteString(fmt.Sprintf("**Workplace**: %s\n\n", w))
+		}
 		f.WriteString(fmt.Sprintf("%s\n\n", printToHTMLAndTruncate(notice.Body, 200)))
 		f.WriteString(fmt.Sprintf("**Read more**: [Here](https://workfindy.com/%s)\n\n", html.EscapeString(notice.ID)))
 		f.WriteString("---\n\n")
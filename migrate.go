@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/store"
+)
+
+const migrateUsage = "usage: migrate [up | down [steps] | status]"
+
+// runMigrate applies or rolls back the embedded schema migrations.
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if command != "up" && command != "down" && command != "status" {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := openBackend(ctx, cfg.Database)
+	if err != nil {
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+	defer db.Close()
+	migrator := db.Migrations
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("Applied migration %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("Database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number\n%s", migrateUsage)
+			}
+		}
+
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, m := range rolledBack {
+			log.Printf("Rolled back migration %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+	}
+
+	return nil
+}
+
+// checkMigrations returns an error when db has migrations that weren't
+// applied, the queries would fail on the tables and columns they add.
+// SQLite is migrated as it is opened, Postgres only by "migrate up".
+func checkMigrations(ctx context.Context, db *store.Backend) error {
+	statuses, err := db.Migrations.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to check the migrations: %w", err)
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("the database has pending migrations %s, run \"migrate up\" first", strings.Join(pending, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Package cassette records HTTP responses to a file and replays them, so the
+// scrapers can be tested against frozen payloads without a network.
+//
+// Tests replay by default. Set RECORD_CASSETTES=1 to make the real requests
+// and overwrite the cassettes with what comes back.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// RecordEnv switches Load to record mode when set.
+const RecordEnv = "RECORD_CASSETTES"
+
+type Mode int
+
+const (
+	// Replay answers every request from the cassette
+	Replay Mode = iota
+	// Record makes the real request and adds the response to the cassette
+	Record
+)
+
+// Interaction is a recorded request and its response. Request headers and
+// bodies aren't kept since they carry credentials.
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette.
+type Recorder struct {
+	path string
+	mode Mode
+	real http.RoundTripper
+	// Filter edits an interaction before it is saved, e.g. to drop a token
+	Filter func(i *Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed map[*Interaction]bool
+}
+
+// New returns a recorder for the cassette at path. In Replay mode the file
+// must exist, in Record mode requests go through real, or
+// http.DefaultTransport when it's nil.
+func New(path string, mode Mode, real http.RoundTripper) (*Recorder, error) {
+	if real == nil {
+		real = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, real: real, replayed: map[*Interaction]bool{}}
+
+	if mode == Replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: unable to read %s: %w", path, err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: unable to parse %s: %w", path, err)
+		}
+	}
+
+	return r, nil
+}
+
+// Load returns a recorder for testdata/<name>.json, in Record mode when
+// RECORD_CASSETTES is set. Recorded cassettes are saved when the test ends.
+func Load(t testing.TB, name string) *Recorder {
+	t.Helper()
+
+	mode := Replay
+	if os.Getenv(RecordEnv) != "" {
+		mode = Record
+	}
+
+	r, err := New(filepath.Join("testdata", name+".json"), mode, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if mode == Record {
+		t.Cleanup(func() {
+			if err := r.Save(); err != nil {
+				t.Errorf("%v", err)
+			}
+		})
+	}
+
+	return r
+}
+
+// Client returns an http.Client that sends every request through r.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == Record {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	resp, err := r.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: resp.StatusCode,
+		Header: header,
+		Body:   string(body),
+	})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// replay answers with the first interaction matching the method and URL that
+// hasn't been replayed yet. Once all of them have, the last one is repeated.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := matchKey(req.Method, req.URL)
+
+	var match *Interaction
+	for _, i := range r.cassette.Interactions {
+		u, err := url.Parse(i.URL)
+		if err != nil || matchKey(i.Method, u) != key {
+			continue
+		}
+		match = i
+		if !r.replayed[i] {
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("cassette: no recorded response for %s %s in %s", req.Method, req.URL, r.path)
+	}
+	r.replayed[match] = true
+
+	status := match.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        match.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(match.Body)),
+		ContentLength: int64(len(match.Body)),
+		Request:       req,
+	}, nil
+}
+
+// matchKey ignores the order of query parameters, some clients build them
+// from a map.
+func matchKey(method string, u *url.URL) string {
+	return strings.ToUpper(method) + " " + u.Scheme + "://" + u.Host + u.Path + "?" + u.Query().Encode()
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return errors.New("cassette: only recorded cassettes can be saved")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Filter != nil {
+		for _, i := range r.cassette.Interactions {
+			r.Filter(i)
+		}
+	}
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
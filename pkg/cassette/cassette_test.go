@@ -0,0 +1,103 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(`{"hit":` + strconv.Itoa(hits) + `,"token":"secret"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "testdata", "example.json")
+
+	recorder, err := New(path, Record, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	recorder.Filter = func(i *Interaction) {
+		i.Body = strings.ReplaceAll(i.Body, "secret", "redacted")
+	}
+
+	client := recorder.Client()
+	get(t, client, server.URL+"/items?a=1&b=2")
+	get(t, client, server.URL+"/items?a=1&b=2")
+	get(t, client, server.URL+"/missing")
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	replayer, err := New(path, Replay, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client = replayer.Client()
+
+	// Same responses in the same order, whatever the order of the query
+	testCases := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"/items?b=2&a=1", 200, `{"hit":1,"token":"redacted"}`},
+		{"/items?a=1&b=2", 200, `{"hit":2,"token":"redacted"}`},
+		{"/items?a=1&b=2", 200, `{"hit":2,"token":"redacted"}`},
+		{"/missing", 404, "404 page not found\n"},
+	}
+	for _, tc := range testCases {
+		status, body := get(t, client, server.URL+tc.url)
+		if status != tc.status || body != tc.body {
+			t.Errorf("Expected %d %q for %s, got %d %q", tc.status, tc.body, tc.url, status, body)
+		}
+	}
+
+	if hits != 3 {
+		t.Errorf("Expected the server to be hit only while recording, got %d hits", hits)
+	}
+
+	if _, err := client.Get(server.URL + "/other"); err == nil || !strings.Contains(err.Error(), "no recorded response for GET") {
+		t.Errorf("Expected an error for an unrecorded request, got %v", err)
+	}
+
+	resp, _ := client.Get(server.URL + "/items?a=1&b=2")
+	if resp.Header.Get("Set-Cookie") != "" || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected headers %v", resp.Header)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), Replay, nil); err == nil {
+		t.Errorf("Expected an error for a missing cassette")
+	}
+}
@@ -0,0 +1,424 @@
+package config
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default.yaml
+var defaultConfig []byte
+
+type Config struct {
+	// Timeout is the most a run may spend fetching, sources still running
+	// after it are reported as timed out
+	Timeout  time.Duration `yaml:"timeout"`
+	Database Database      `yaml:"database"`
+	HTTP     HTTP          `yaml:"http"`
+	Daemon   Daemon        `yaml:"daemon"`
+	Sources  []Source      `yaml:"sources"`
+	// Keywords are the technologies inserted notices are tagged with, the
+	// built in dictionary when left out
+	Keywords []Keyword `yaml:"keywords"`
+	Notify   Notify    `yaml:"notify"`
+}
+
+// Notify picks what is sent besides the new notices.
+type Notify struct {
+	// Updates sends the notices that were edited since they were stored, to
+	// the notifiers that support it
+	Updates bool `yaml:"updates"`
+}
+
+// Database picks the storage backend. For Postgres the connection string
+// comes from DATABASE_URL since it holds credentials, the rest tunes the pool.
+type Database struct {
+	// Driver is postgres or sqlite
+	Driver string `yaml:"driver"`
+	// Path is the file of the sqlite driver
+	Path            string        `yaml:"path"`
+	MaxConns        int32         `yaml:"max_conns"`
+	MinConns        int32         `yaml:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+	// SSLMode overrides the sslmode of DATABASE_URL
+	SSLMode string `yaml:"sslmode"`
+}
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// HTTP tunes the client every scraper makes its requests with. Failed GET
+// requests are retried with a jittered exponential backoff.
+type HTTP struct {
+	// MaxAttempts caps the tries of a request, including the first
+	MaxAttempts int `yaml:"max_attempts"`
+	// BaseDelay is the wait before the first retry, doubled for every retry
+	BaseDelay time.Duration `yaml:"base_delay"`
+	// MaxDelay caps the wait between two tries, a longer Retry-After isn't
+	// waited for
+	MaxDelay time.Duration `yaml:"max_delay"`
+	// MaxInFlight caps the requests made at once by every source together
+	MaxInFlight int `yaml:"max_in_flight"`
+	// RateLimit is applied to every host on its own
+	RateLimit RateLimit `yaml:"rate_limit"`
+}
+
+// RateLimit is a token bucket allowing Rate requests a second on average,
+// with bursts of up to Burst. A zero Rate doesn't limit.
+type RateLimit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// Daemon holds the settings of the -daemon mode.
+type Daemon struct {
+	// StateFile is where the last run of every source is kept between restarts
+	StateFile string `yaml:"state_file"`
+	// Jitter is the most a run is randomly delayed by
+	Jitter time.Duration `yaml:"jitter"`
+}
+
+// defaultIntervals is how often each type of source runs in daemon mode
+// unless it sets an interval.
+var defaultIntervals = map[string]time.Duration{
+	"hackernews": time.Hour,
+	"rss":        15 * time.Minute,
+	"reddit":     10 * time.Minute,
+}
+
+// defaultTimeouts is how long each type of source may take to fetch unless
+// it sets a timeout.
+var defaultTimeouts = map[string]time.Duration{
+	"hackernews": 5 * time.Minute,
+	"rss":        time.Minute,
+	"reddit":     time.Minute,
+}
+
+// DefaultTimeout is the timeout of a whole run unless the config sets one.
+const DefaultTimeout = 10 * time.Minute
+
+// Source declares a single job board. Which of the fields are used depends
+// on its type.
+type Source struct {
+	Name        string   `yaml:"name"`
+	Type        string   `yaml:"type"`
+	Enabled     *bool    `yaml:"enabled"`
+	Description string   `yaml:"description"`
+	Homepage    string   `yaml:"homepage"`
+	URLs        []string `yaml:"urls"`
+	Subreddits  []string `yaml:"subreddits"`
+	Thread      string   `yaml:"thread"`
+	Limit       int      `yaml:"limit"`
+	Workers     int      `yaml:"workers"`
+	Filters     Filters  `yaml:"filters"`
+	// CompanyInTitle reads the company of an rss item without one from its
+	// title, for feeds titled like "Acme: Backend Engineer"
+	CompanyInTitle bool `yaml:"company_in_title"`
+	// Interval is how often the source runs in daemon mode
+	Interval time.Duration `yaml:"interval"`
+	// Timeout is the most a single fetch of the source may take
+	Timeout time.Duration `yaml:"timeout"`
+	// RateLimit limits the requests of the source to each host, on top of
+	// the limit of the host shared with the other sources
+	RateLimit RateLimit `yaml:"rate_limit"`
+}
+
+// Filters match against the title of a notice, ignoring case. A notice is
+// kept when it contains any of Include, or Include is empty, and none of
+// Exclude.
+type Filters struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// Load reads the config at path, or the embedded default when path is empty.
+func Load(path string) (*Config, error) {
+	data := defaultConfig
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config: %w", err)
+		}
+	}
+
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse config: %w", err)
+	}
+
+	cfg.applyDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) applyDefaults() {
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.Database.Driver == "" {
+		c.Database.Driver = DriverPostgres
+	}
+	if c.Database.Driver == DriverSQLite && c.Database.Path == "" {
+		c.Database.Path = "scraper.db"
+	}
+	if c.HTTP.MaxAttempts == 0 {
+		c.HTTP.MaxAttempts = 4
+	}
+	if c.HTTP.BaseDelay == 0 {
+		c.HTTP.BaseDelay = 500 * time.Millisecond
+	}
+	if c.HTTP.MaxDelay == 0 {
+		c.HTTP.MaxDelay = 30 * time.Second
+	}
+	if c.HTTP.MaxInFlight == 0 {
+		c.HTTP.MaxInFlight = 16
+	}
+	if c.HTTP.RateLimit.Rate == 0 {
+		c.HTTP.RateLimit = RateLimit{Rate: 10, Burst: 10}
+	}
+	c.HTTP.RateLimit.applyDefaults()
+	if c.Daemon.StateFile == "" {
+		c.Daemon.StateFile = ".scraper-state.json"
+	}
+	if c.Daemon.Jitter == 0 {
+		c.Daemon.Jitter = 30 * time.Second
+	}
+
+	if c.Keywords == nil {
+		c.Keywords = DefaultKeywords()
+	}
+
+	for i := range c.Sources {
+		if c.Sources[i].Interval == 0 {
+			c.Sources[i].Interval = defaultIntervals[c.Sources[i].Type]
+		}
+		if c.Sources[i].Timeout == 0 {
+			c.Sources[i].Timeout = defaultTimeouts[c.Sources[i].Type]
+		}
+		c.Sources[i].RateLimit.applyDefaults()
+	}
+}
+
+// Validate checks every source and reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	if len(c.Sources) == 0 {
+		errs = append(errs, errors.New("no sources configured"))
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, errors.New("timeout can't be negative"))
+	}
+	if c.Daemon.Jitter < 0 {
+		errs = append(errs, errors.New("daemon: jitter can't be negative"))
+	}
+	errs = append(errs, c.Database.validate()...)
+	errs = append(errs, c.HTTP.validate()...)
+	errs = append(errs, validateKeywords(c.Keywords)...)
+
+	names := map[string]bool{}
+	for i, s := range c.Sources {
+		label := s.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+
+		for _, err := range s.validate() {
+			errs = append(errs, fmt.Errorf("source %s: %w", label, err))
+		}
+
+		if s.Name != "" && names[s.Name] {
+			errs = append(errs, fmt.Errorf("source %s: name is used more than once", label))
+		}
+		names[s.Name] = true
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Database) validate() []error {
+	var errs []error
+	if d.Driver != DriverPostgres && d.Driver != DriverSQLite {
+		errs = append(errs, fmt.Errorf("database: driver must be %s or %s", DriverPostgres, DriverSQLite))
+	}
+	if d.MaxConns < 0 || d.MinConns < 0 {
+		errs = append(errs, errors.New("database: connection counts can't be negative"))
+	}
+	if d.MaxConns > 0 && d.MinConns > d.MaxConns {
+		errs = append(errs, errors.New("database: min_conns can't be more than max_conns"))
+	}
+	if d.SSLMode != "" && !slices.Contains(sslModes, d.SSLMode) {
+		errs = append(errs, fmt.Errorf("database: sslmode must be one of %s", strings.Join(sslModes, ", ")))
+	}
+	return errs
+}
+
+func (h *HTTP) validate() []error {
+	var errs []error
+	if h.MaxAttempts < 1 {
+		errs = append(errs, errors.New("http: max_attempts must be at least 1"))
+	}
+	if h.BaseDelay < 0 || h.MaxDelay < 0 {
+		errs = append(errs, errors.New("http: delays can't be negative"))
+	}
+	if h.MaxInFlight < 0 {
+		errs = append(errs, errors.New("http: max_in_flight can't be negative"))
+	}
+	if err := h.RateLimit.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("http: %w", err))
+	}
+	return errs
+}
+
+// applyDefaults lets a bucket hold at least a second of requests when its
+// burst isn't set.
+func (r *RateLimit) applyDefaults() {
+	if r.Rate > 0 && r.Burst == 0 {
+		r.Burst = max(1, int(math.Ceil(r.Rate)))
+	}
+}
+
+func (r *RateLimit) validate() error {
+	if r.Rate < 0 || r.Burst < 0 {
+		return errors.New("rate_limit can't be negative")
+	}
+	return nil
+}
+
+// HackerNewsThreads are the values the thread of a hackernews source can take.
+var HackerNewsThreads = []string{"hiring", "seekers", "freelance"}
+
+var (
+	typesMu sync.Mutex
+	types   = map[string]bool{}
+)
+
+// RegisterType makes kind a valid source type. source.Register calls it for
+// every type it can build, so a config with a typo in a type fails to load
+// before anything is opened.
+func RegisterType(kind string) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	types[kind] = true
+}
+
+// typeNames lists the registered source types in order.
+func typeNames() []string {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	names := make([]string, 0, len(types))
+	for kind := range types {
+		names = append(names, kind)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (s *Source) validate() []error {
+	var errs []error
+	if s.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if s.Limit < 0 {
+		errs = append(errs, errors.New("limit can't be negative"))
+	}
+	if s.Workers < 0 {
+		errs = append(errs, errors.New("workers can't be negative"))
+	}
+	if s.Interval < 0 {
+		errs = append(errs, errors.New("interval can't be negative"))
+	}
+	if s.Timeout < 0 {
+		errs = append(errs, errors.New("timeout can't be negative"))
+	}
+	if err := s.RateLimit.validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	known := typeNames()
+	switch {
+	case s.Type == "":
+		errs = append(errs, errors.New("type is required"))
+	case !slices.Contains(known, s.Type):
+		errs = append(errs, fmt.Errorf("unknown type %q, expected one of %s", s.Type, strings.Join(known, ", ")))
+	}
+
+	switch s.Type {
+	case "rss":
+		if len(s.URLs) == 0 {
+			errs = append(errs, errors.New("at least one url is required"))
+		}
+		for _, u := range s.URLs {
+			parsed, err := url.Parse(u)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				errs = append(errs, fmt.Errorf("invalid url %q", u))
+			}
+		}
+	case "reddit":
+		if len(s.Subreddits) == 0 {
+			errs = append(errs, errors.New("at least one subreddit is required"))
+		}
+	case "hackernews":
+		if s.Thread == "" {
+			errs = append(errs, errors.New("thread is required"))
+		} else if !slices.Contains(HackerNewsThreads, s.Thread) {
+			errs = append(errs, fmt.Errorf("unknown thread %q, expected one of %s", s.Thread, strings.Join(HackerNewsThreads, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func (s *Source) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// Match reports whether notice passes the filters.
+func (f *Filters) Match(notice *models.Notice) bool {
+	title := strings.ToLower(notice.Title)
+
+	for _, word := range f.Exclude {
+		if strings.Contains(title, strings.ToLower(word)) {
+			return false
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, word := range f.Include {
+		if strings.Contains(title, strings.ToLower(word)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *Filters) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadDefault(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Sources) == 0 {
+		t.Errorf("Expected default sources")
+	}
+}
+
+func TestTimeoutDefaults(t *testing.T) {
+	cfg, err := Parse([]byte(`
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+  - name: Remotive
+    type: rss
+    urls: [https://remotive.com/remote-jobs/feed/software-dev]
+    timeout: 10s
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.Timeout != DefaultTimeout {
+		t.Errorf("Expected run timeout %s, got %s", DefaultTimeout, cfg.Timeout)
+	}
+	if cfg.Sources[0].Timeout != 5*time.Minute {
+		t.Errorf("Expected the hackernews default of 5m, got %s", cfg.Sources[0].Timeout)
+	}
+	if cfg.Sources[1].Timeout != 10*time.Second {
+		t.Errorf("Expected the configured timeout of 10s, got %s", cfg.Sources[1].Timeout)
+	}
+}
+
+func TestRateLimitDefaults(t *testing.T) {
+	cfg, err := Parse([]byte(`
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+    rate_limit:
+      rate: 2.5
+  - name: Reddit
+    type: reddit
+    subreddits: [forhire]
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.HTTP.RateLimit != (RateLimit{Rate: 10, Burst: 10}) || cfg.HTTP.MaxInFlight != 16 {
+		t.Errorf("Unexpected http defaults %+v", cfg.HTTP)
+	}
+	if cfg.Sources[0].RateLimit != (RateLimit{Rate: 2.5, Burst: 3}) {
+		t.Errorf("Expected a burst of a second of requests, got %+v", cfg.Sources[0].RateLimit)
+	}
+	if cfg.Sources[1].RateLimit != (RateLimit{}) {
+		t.Errorf("Expected no limit of the source, got %+v", cfg.Sources[1].RateLimit)
+	}
+}
+
+func TestKeywordDefaults(t *testing.T) {
+	cfg, err := Parse([]byte(`
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Keywords) != len(defaultKeywords) {
+		t.Errorf("Expected the built in dictionary, got %d keywords", len(cfg.Keywords))
+	}
+
+	cfg, err = Parse([]byte(`
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+keywords:
+  - name: Zig
+    aliases: [ziglang]
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Keywords) != 1 || cfg.Keywords[0].Name != "Zig" {
+		t.Errorf("Expected the configured keywords to replace the dictionary, got %+v", cfg.Keywords)
+	}
+}
+
+func init() {
+	// The scraper packages register their types, which the config package
+	// can't import
+	for _, kind := range []string{"rss", "reddit", "hackernews"} {
+		RegisterType(kind)
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+		errors []string
+	}{
+		{
+			name: "Valid config",
+			config: `
+sources:
+  - name: WeWorkRemotely
+    type: rss
+    urls: [https://weworkremotely.com/categories/remote-devops-sysadmin-jobs.rss]
+  - name: Reddit
+    type: reddit
+    enabled: false
+    subreddits: [forhire]
+`,
+		},
+		{
+			name: "Every problem is reported",
+			config: `
+sources:
+  - type: rss
+    urls: [not a url]
+  - name: Reddit
+    type: reddit
+  - name: Reddit
+    type: hackernews
+    limit: -1
+`,
+			errors: []string{
+				"source #1: name is required",
+				`source #1: invalid url "not a url"`,
+				"source Reddit: at least one subreddit is required",
+				"source Reddit: thread is required",
+				"source Reddit: limit can't be negative",
+				"source Reddit: name is used more than once",
+			},
+		},
+		{
+			name: "Unknown database driver",
+			config: `
+database:
+  driver: mysql
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+`,
+			errors: []string{"database: driver must be postgres or sqlite"},
+		},
+		{
+			name: "Negative timeouts",
+			config: `
+timeout: -1m
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+    timeout: -30s
+`,
+			errors: []string{
+				"timeout can't be negative",
+				"source HackerNews: timeout can't be negative",
+			},
+		},
+		{
+			name: "Invalid retries",
+			config: `
+http:
+  max_attempts: -1
+  base_delay: -1s
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+`,
+			errors: []string{
+				"http: max_attempts must be at least 1",
+				"http: delays can't be negative",
+			},
+		},
+		{
+			name: "Negative rate limits",
+			config: `
+http:
+  rate_limit:
+    rate: -1
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+    rate_limit:
+      burst: -2
+`,
+			errors: []string{
+				"http: rate_limit can't be negative",
+				"source HackerNews: rate_limit can't be negative",
+			},
+		},
+		{
+			name: "Invalid keywords",
+			config: `
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hiring
+keywords:
+  - name: Go
+    aliases: [golang, ""]
+  - name: go
+  - aliases: [k8s]
+`,
+			errors: []string{
+				"keyword Go: aliases can't be empty",
+				"keyword go: name is used more than once",
+				"keyword #3: name is required",
+			},
+		},
+		{
+			name: "Unknown type",
+			config: `
+sources:
+  - name: WeWorkRemotely
+    type: rs
+    urls: [https://weworkremotely.com/remote-jobs.rss]
+`,
+			errors: []string{`source WeWorkRemotely: unknown type "rs", expected one of hackernews, reddit, rss`},
+		},
+		{
+			name: "Unknown thread",
+			config: `
+sources:
+  - name: HackerNews
+    type: hackernews
+    thread: hireing
+`,
+			errors: []string{`source HackerNews: unknown thread "hireing", expected one of hiring, seekers, freelance`},
+		},
+		{
+			name:   "No sources",
+			config: `sources: []`,
+			errors: []string{"no sources configured"},
+		},
+		{
+			name:   "Malformed yaml",
+			config: `sources: [`,
+			errors: []string{"unable to parse config"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.config))
+			if len(tc.errors) == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("Expected error but got none")
+			}
+			for _, expected := range tc.errors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keyword is a technology notices are tagged with. It is found by its name
+// or any of its aliases as a whole word, ignoring case. Names that are also
+// common words, like Go, can be matched with their case only.
+type Keyword struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+	// CaseSensitive matches the name with its case, aliases still ignore it
+	CaseSensitive bool `yaml:"case_sensitive"`
+}
+
+// defaultKeywords is the dictionary used unless the config sets keywords.
+var defaultKeywords = []Keyword{
+	{Name: "Go", Aliases: []string{"golang"}, CaseSensitive: true},
+	{Name: "Rust", CaseSensitive: true},
+	{Name: "Python"},
+	{Name: "Java"},
+	{Name: "Kotlin"},
+	{Name: "Scala"},
+	{Name: "Swift", CaseSensitive: true},
+	{Name: "Ruby", CaseSensitive: true},
+	{Name: "Rails", Aliases: []string{"ruby on rails", "ror"}, CaseSensitive: true},
+	{Name: "PHP"},
+	{Name: "Laravel"},
+	{Name: "Elixir"},
+	{Name: "Haskell"},
+	{Name: "C++", Aliases: []string{"cpp"}},
+	{Name: "C#", Aliases: []string{"csharp"}},
+	{Name: ".NET", Aliases: []string{"dotnet"}},
+	{Name: "JavaScript", Aliases: []string{"js", "ecmascript"}},
+	{Name: "TypeScript"},
+	{Name: "React", Aliases: []string{"reactjs", "react.js"}, CaseSensitive: true},
+	{Name: "React Native"},
+	{Name: "Vue", Aliases: []string{"vuejs", "vue.js"}},
+	{Name: "Angular", Aliases: []string{"angularjs"}},
+	{Name: "Svelte"},
+	{Name: "Node.js", Aliases: []string{"nodejs"}},
+	{Name: "Django"},
+	{Name: "Flask", CaseSensitive: true},
+	{Name: "Spring", Aliases: []string{"spring boot"}, CaseSensitive: true},
+	{Name: "GraphQL"},
+	{Name: "Kubernetes", Aliases: []string{"k8s"}},
+	{Name: "Docker"},
+	{Name: "Terraform"},
+	{Name: "AWS", Aliases: []string{"amazon web services"}},
+	{Name: "GCP", Aliases: []string{"google cloud"}},
+	{Name: "Azure"},
+	{Name: "PostgreSQL", Aliases: []string{"postgres"}},
+	{Name: "MySQL"},
+	{Name: "MongoDB", Aliases: []string{"mongo"}},
+	{Name: "Redis"},
+	{Name: "Kafka"},
+	{Name: "Elasticsearch"},
+	{Name: "Linux"},
+	{Name: "iOS"},
+	{Name: "Android"},
+	{Name: "Flutter"},
+	{Name: "Machine Learning", Aliases: []string{"ml"}},
+}
+
+// DefaultKeywords returns a copy of the built in dictionary.
+func DefaultKeywords() []Keyword {
+	keywords := make([]Keyword, len(defaultKeywords))
+	for i, k := range defaultKeywords {
+		k.Aliases = append([]string(nil), k.Aliases...)
+		keywords[i] = k
+	}
+	return keywords
+}
+
+func validateKeywords(keywords []Keyword) []error {
+	var errs []error
+	names := map[string]bool{}
+	for i, k := range keywords {
+		label := k.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+			errs = append(errs, fmt.Errorf("keyword %s: name is required", label))
+		}
+		if names[strings.ToLower(k.Name)] {
+			errs = append(errs, fmt.Errorf("keyword %s: name is used more than once", label))
+		}
+		names[strings.ToLower(k.Name)] = true
+
+		for _, alias := range k.Aliases {
+			if strings.TrimSpace(alias) == "" {
+				errs = append(errs, fmt.Errorf("keyword %s: aliases can't be empty", label))
+			}
+		}
+	}
+	return errs
+}
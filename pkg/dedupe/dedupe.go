@@ -0,0 +1,261 @@
+// Package dedupe finds the notices that are the same job posted on more than
+// one board. Notices are compared by their normalised company and title, and
+// by the SimHash of their body. The store looks up the notices to compare
+// with by title, and by the 16 bit bands of their SimHash.
+package dedupe
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/keywords"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// Window is how far back a notice is looked for duplicates.
+const Window = 30 * 24 * time.Hour
+
+const (
+	// shingleSize is the number of words hashed together
+	shingleSize = 3
+	// minShingles is the fewest shingles a body needs to be compared on its
+	// own, shorter ones are too alike by chance
+	minShingles = 20
+	// sameBody is the most bits the SimHashes of two copies of a body differ
+	// by. It has to stay below the 4 bands SimHashes are looked up by, so
+	// copies always have a band in common
+	sameBody = 3
+	// similarBody is the most they differ by when the titles are the same
+	// but no company is known
+	similarBody = 10
+)
+
+var (
+	// bracketsRe matches the asides of a title, like "(Remote)" or
+	// "[Hiring]"
+	bracketsRe = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+	// atRe splits "Engineer at Acme" into its role and company
+	atRe = regexp.MustCompile(`^(.+?)\s+(?:at|@)\s+(.+)$`)
+	// titleNoise are words that differ between boards posting the same job
+	titleNoise = map[string]bool{
+		"remote": true, "hiring": true, "fulltime": true, "full": true, "time": true,
+		"parttime": true, "contract": true, "job": true, "position": true, "role": true,
+	}
+	// companySuffixes are the legal forms dropped from company names
+	companySuffixes = map[string]bool{
+		"inc": true, "llc": true, "ltd": true, "limited": true, "gmbh": true, "corp": true,
+		"corporation": true, "co": true, "sa": true, "bv": true, "ag": true, "plc": true,
+	}
+)
+
+// Fingerprint is what notices are compared on.
+type Fingerprint struct {
+	// Company and Title are normalised, Company is empty when unknown
+	Company string
+	Title   string
+	SimHash uint64
+	// Shingles counts the shingles of the body
+	Shingles int
+}
+
+// Key is the normalised title and company, e.g. "backend engineer|acme".
+// The title comes first so the keys of a title can be looked up as a range.
+func (f *Fingerprint) Key() string {
+	return f.Title + "|" + f.Company
+}
+
+// Of fingerprints notice. The company is the one a source parsed, dropped
+// from the title when it is repeated there like in "Acme: Engineer" or
+// "Engineer at Acme". Without one it is read from titles like the latter.
+func Of(notice *models.Notice) *Fingerprint {
+	title := bracketsRe.ReplaceAllString(notice.Title, " ")
+	company := ""
+	if notice.Company != nil {
+		company = normaliseCompany(*notice.Company)
+	}
+
+	if before, after, ok := strings.Cut(title, ":"); ok && company != "" && normaliseCompany(before) == company {
+		title = after
+	} else if match := atRe.FindStringSubmatch(title); match != nil && (company == "" || normaliseCompany(match[2]) == company) {
+		title, company = match[1], normaliseCompany(match[2])
+	}
+
+	body := words(keywords.PlainText(notice.Body))
+	hash, shingles := simHash(body)
+	return &Fingerprint{
+		Company:  company,
+		Title:    strings.Join(without(words(title), titleNoise), " "),
+		SimHash:  hash,
+		Shingles: shingles,
+	}
+}
+
+func normaliseCompany(company string) string {
+	return strings.Join(without(words(company), companySuffixes), " ")
+}
+
+// Apply stores the fingerprint on notice.
+func (f *Fingerprint) Apply(notice *models.Notice) {
+	key := f.Key()
+	notice.DedupeKey = &key
+	if f.Shingles > 0 {
+		hash := int64(f.SimHash)
+		notice.SimHash = &hash
+	}
+}
+
+// BodyHash is the SimHash to look up copies of the body by, nil when the
+// body is too short to be compared on its own.
+func (f *Fingerprint) BodyHash() *int64 {
+	if f.Shingles < minShingles {
+		return nil
+	}
+	hash := int64(f.SimHash)
+	return &hash
+}
+
+// IsDuplicate is true when f and other are the same job. Notices with the
+// same company and title are, without a company they also need a similar
+// body. Long bodies that are nearly the same are duplicates whatever their
+// titles.
+func (f *Fingerprint) IsDuplicate(other *Fingerprint) bool {
+	bodies := f.Shingles > 0 && other.Shingles > 0
+	differ := distance(f.SimHash, other.SimHash)
+
+	if f.Title != "" && f.Title == other.Title {
+		if f.Company != "" && f.Company == other.Company {
+			return true
+		}
+		if (f.Company == "" || other.Company == "") && bodies && differ <= similarBody {
+			return true
+		}
+	}
+	return bodies && f.Shingles >= minShingles && other.Shingles >= minShingles && differ <= sameBody
+}
+
+// Index holds the notices duplicates are looked for in, with their
+// fingerprints so they are only computed once.
+type Index struct {
+	notices []*models.Notice
+	prints  []*Fingerprint
+}
+
+// NewIndex indexes notices, the first ones are preferred as canonical.
+func NewIndex(notices []*models.Notice) *Index {
+	ix := &Index{}
+	for _, notice := range notices {
+		ix.Add(notice)
+	}
+	return ix
+}
+
+// Add indexes notice.
+func (ix *Index) Add(notice *models.Notice) {
+	ix.notices = append(ix.notices, notice)
+	ix.prints = append(ix.prints, Of(notice))
+}
+
+// Find returns the indexed notice notice is a duplicate of, nil when there
+// is none. A notice with the same id, or the same guid on the same source,
+// is notice itself and is skipped.
+func (ix *Index) Find(notice *models.Notice) *models.Notice {
+	f := Of(notice)
+	for i, candidate := range ix.notices {
+		if candidate.ID == notice.ID || (candidate.SourceID == notice.SourceID && candidate.Guid == notice.Guid) {
+			continue
+		}
+		if f.IsDuplicate(ix.prints[i]) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// AlsoOn drops the notices that are duplicates of another one in notices,
+// and returns the rest with the sources their duplicates were found on,
+// keyed by notice id.
+func AlsoOn(notices []*models.Notice) ([]*models.Notice, map[string][]string) {
+	byID := map[string]bool{}
+	for _, notice := range notices {
+		byID[notice.ID] = true
+	}
+
+	var canonical []*models.Notice
+	alsoOn := map[string][]string{}
+	for _, notice := range notices {
+		if notice.CanonicalID == nil {
+			canonical = append(canonical, notice)
+			continue
+		}
+		if !byID[*notice.CanonicalID] {
+			continue
+		}
+		sources := alsoOn[*notice.CanonicalID]
+		if !slices.Contains(sources, notice.SourceID) {
+			alsoOn[*notice.CanonicalID] = append(sources, notice.SourceID)
+		}
+	}
+	return canonical, alsoOn
+}
+
+// distance counts the bits two SimHashes differ by.
+func distance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// words lowercases text and splits it on anything that isn't a letter or a
+// digit, so "Full-Time" and "full time" are the same.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func without(values []string, drop map[string]bool) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if !drop[v] {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// simHash hashes every shingle of words and returns the SimHash of the body
+// along with the number of shingles. A body shorter than a shingle is hashed
+// as one.
+func simHash(words []string) (uint64, int) {
+	if len(words) == 0 {
+		return 0, 0
+	}
+
+	var counts [64]int
+	shingles := 0
+	for i := 0; i+shingleSize <= len(words) || i == 0; i++ {
+		end := min(i+shingleSize, len(words))
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:end], " ")))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				counts[bit]++
+			} else {
+				counts[bit]--
+			}
+		}
+		shingles++
+	}
+
+	var hash uint64
+	for bit, count := range counts {
+		if count > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash, shingles
+}
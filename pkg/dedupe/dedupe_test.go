@@ -0,0 +1,154 @@
+package dedupe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// description is long enough for the body to be compared on its own.
+const description = `<p>We are a small team building payment APIs used by thousands of
+merchants. You will design and run the services that move money between banks,
+work closely with product on new features, and help us keep the platform fast
+and reliable. We use Go, Postgres and Kubernetes.</p>`
+
+func notice(source string, guid string, title string, body string) *models.Notice {
+	return &models.Notice{ID: source + "-" + guid, SourceID: source, Guid: guid, Title: title, Body: body}
+}
+
+// posted sets the company a source parsed for n.
+func posted(n *models.Notice, company string) *models.Notice {
+	n.Company = &company
+	return n
+}
+
+func TestOf(t *testing.T) {
+	company := "Acme, Inc."
+	testCases := []struct {
+		name    string
+		notice  *models.Notice
+		company string
+		title   string
+	}{
+		{"Company and title", posted(notice("RSS", "1", "Acme: Senior Backend Engineer", ""), "Acme"), "acme", "senior backend engineer"},
+		{"Colon without a company", notice("RSS", "1", "Senior Engineer: Backend", ""), "", "senior engineer backend"},
+		{"Colon with another company", posted(notice("RSS", "1", "Senior Engineer: Backend", ""), "Acme"), "acme", "senior engineer backend"},
+		{"At company", notice("HN", "1", "Senior Backend Engineer at Acme Ltd", ""), "acme", "senior backend engineer"},
+		{"Parsed company", &models.Notice{Title: "Senior Backend Engineer (Remote)", Company: &company}, "acme", "senior backend engineer"},
+		{"Noise", notice("Reddit", "1", "[Hiring] Full-Time Senior Backend Engineer - Remote", ""), "", "senior backend engineer"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := Of(tc.notice)
+			if f.Company != tc.company || f.Title != tc.title {
+				t.Errorf("Expected %q and %q, got %q and %q", tc.company, tc.title, f.Company, f.Title)
+			}
+			if f.Key() != tc.title+"|"+tc.company {
+				t.Errorf("Unexpected key %q", f.Key())
+			}
+		})
+	}
+}
+
+func TestSimHash(t *testing.T) {
+	a, shingles := simHash(words(description))
+	if shingles < minShingles {
+		t.Fatalf("Expected at least %d shingles, got %d", minShingles, shingles)
+	}
+
+	b, _ := simHash(words(strings.Replace(description, "thousands", "hundreds", 1)))
+	if d := distance(a, b); d > similarBody {
+		t.Errorf("Expected a small edit to change few bits, got %d", d)
+	}
+
+	c, _ := simHash(words("We are hiring a designer to draw the illustrations of a children's book about a dragon who learns to cook. Send us your portfolio."))
+	if d := distance(a, c); d <= sameBody {
+		t.Errorf("Expected different bodies to differ, got %d bits", d)
+	}
+
+	if hash, shingles := simHash(nil); hash != 0 || shingles != 0 {
+		t.Errorf("Expected nothing for an empty body, got %d and %d", hash, shingles)
+	}
+	if _, shingles := simHash([]string{"go"}); shingles != 1 {
+		t.Errorf("Expected a short body to be one shingle, got %d", shingles)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	wwr := posted(notice("WeWorkRemotely", "1", "Acme: Backend Engineer", description), "Acme")
+	ix := NewIndex([]*models.Notice{
+		wwr,
+		posted(notice("WeWorkRemotely", "2", "Widgets: Designer", "<p>Draw things</p>"), "Widgets"),
+	})
+
+	testCases := []struct {
+		name     string
+		notice   *models.Notice
+		expected *models.Notice
+	}{
+		{"Same company and title", notice("Remotive", "9", "Backend Engineer at Acme Inc", "<p>Apply now</p>"), wwr},
+		{"Same title and body without a company", notice("Reddit", "9", "[Hiring] Backend Engineer (Remote)", description), wwr},
+		{"Same body", notice("RemoteOK", "9", "Go Developer", description+" "), wwr},
+		{"Same title, other company", notice("Remotive", "9", "Backend Engineer at Quill", description), wwr},
+		{"Same title, other body", notice("Reddit", "9", "Backend Engineer", "<p>Join our agency building websites for restaurants</p>"), nil},
+		{"Itself", notice("WeWorkRemotely", "1", "Acme: Backend Engineer", description), nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if found := ix.Find(tc.notice); found != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, found)
+			}
+		})
+	}
+}
+
+func TestAlsoOn(t *testing.T) {
+	canonical := notice("WeWorkRemotely", "1", "Acme: Backend Engineer", "")
+	other := notice("WeWorkRemotely", "2", "Widgets: Designer", "")
+	var duplicates []*models.Notice
+	for _, source := range []string{"Remotive", "RemoteOK", "Remotive"} {
+		duplicate := notice(source, "1", "Backend Engineer at Acme", "")
+		duplicate.CanonicalID = &canonical.ID
+		duplicates = append(duplicates, duplicate)
+	}
+	// A duplicate of a notice that isn't in the list is dropped
+	missing := "HackerNews-1"
+	orphan := notice("Remotive", "2", "Designer at Widgets", "")
+	orphan.CanonicalID = &missing
+
+	notices, alsoOn := AlsoOn(append([]*models.Notice{canonical, other, orphan}, duplicates...))
+
+	if len(notices) != 2 || notices[0] != canonical || notices[1] != other {
+		t.Errorf("Expected only the canonical notices, got %v", notices)
+	}
+	if strings.Join(alsoOn[canonical.ID], ", ") != "Remotive, RemoteOK" || len(alsoOn) != 1 {
+		t.Errorf("Expected Remotive and RemoteOK once, got %v", alsoOn)
+	}
+}
+
+func TestApply(t *testing.T) {
+	n := posted(notice("WeWorkRemotely", "1", "Acme: Backend Engineer", description), "Acme")
+	Of(n).Apply(n)
+	if n.DedupeKey == nil || *n.DedupeKey != "backend engineer|acme" || n.SimHash == nil {
+		t.Errorf("Expected the fingerprint to be set, got %v %v", n.DedupeKey, n.SimHash)
+	}
+
+	n = notice("WeWorkRemotely", "1", "Acme: Backend Engineer", "")
+	Of(n).Apply(n)
+	if n.SimHash != nil {
+		t.Errorf("Expected no SimHash without a body, got %d", *n.SimHash)
+	}
+}
+
+func TestBodyHash(t *testing.T) {
+	f := Of(notice("WeWorkRemotely", "1", "Backend Engineer", description))
+	if hash := f.BodyHash(); hash == nil || uint64(*hash) != f.SimHash {
+		t.Errorf("Expected the SimHash of a long body, got %v", hash)
+	}
+	if hash := Of(notice("WeWorkRemotely", "1", "Backend Engineer", "<p>Go</p>")).BodyHash(); hash != nil {
+		t.Errorf("Expected no SimHash for a short body, got %d", *hash)
+	}
+}
@@ -1,14 +1,19 @@
 package discord
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/bwmarrin/discordgo"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/salary"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/workplace"
 )
 
 func InitDiscordClient() (*discordgo.Session, error) {
@@ -21,52 +26,183 @@ func InitDiscordClient() (*discordgo.Session, error) {
 	return bot, nil
 }
 
+// Client sends to the hiring channel.
+type Client struct {
+	bot *discordgo.Session
+}
+
+func NewClient() (*Client, error) {
+	bot, err := InitDiscordClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{bot: bot}, nil
+}
+
+func (c *Client) SendList(ctx context.Context, notices []*models.Notice, alsoOn map[string][]string) {
+	SendList(ctx, c.bot, notices, alsoOn)
+}
+
+func (c *Client) SendUpdates(ctx context.Context, notices []*models.Notice) {
+	SendUpdates(ctx, c.bot, notices)
+}
+
+func (c *Client) SendReport(ctx context.Context, summary Summary) error {
+	return SendReport(ctx, c.bot, summary)
+}
+
+// Summary is what a run did, sent once it's done.
+type Summary struct {
+	// Scraped counts the notices that passed the filters
+	Scraped int
+	New     int
+	// Failed counts the notices that couldn't be inserted
+	Failed int
+	// Duplicates counts the new notices of jobs found before, which aren't
+	// sent again
+	Duplicates int
+	// Updated counts the stored notices that were edited
+	Updated int
+	Runtime time.Duration
+	// Sources are the results of every source fetched in the run
+	Sources source.Report
+}
+
+// maxMessageLength is the most characters Discord takes in a message.
+const maxMessageLength = 2000
+
 func convertToSiteUrl(id string) string {
 	return fmt.Sprintf("https://workfindy.com/%s", id)
 }
 
-func sendEmbed(bot *discordgo.Session, channelID string, embed *discordgo.MessageEmbed) {
-	_, err := bot.ChannelMessageSendEmbed(channelID, embed)
+func sendEmbed(ctx context.Context, bot *discordgo.Session, channelID string, embed *discordgo.MessageEmbed) {
+	_, err := bot.ChannelMessageSendEmbed(channelID, embed, discordgo.WithContext(ctx))
 
 	if err != nil {
 		log.Printf("Failed to send embed: %v", err) // Log the error or handle it as you prefer
 	}
 }
 
-func SendList(bot *discordgo.Session, notices []models.Notice) {
+// noticeEmbed formats a notice for the hiring channel, truncating long
+// titles and bodies. alsoOn are the other sources the notice was found on.
+func noticeEmbed(n *models.Notice, alsoOn []string) *discordgo.MessageEmbed {
 	truncateBody := 500
 	truncateTitle := 250
-	for _, notice := range notices {
-		converter := md.NewConverter("", true, nil)
-		notice.Body, _ = converter.ConvertString(notice.Body)
 
-		notice.Body = fmt.Sprintf("From %s \n %s", notice.SourceID, notice.Body)
+	// Work on a copy, the caller's notices shouldn't be truncated
+	notice := *n
+	converter := md.NewConverter("", true, nil)
+	notice.Body, _ = converter.ConvertString(notice.Body)
 
-		if len(notice.Body) > truncateBody {
-			notice.Body = notice.Body[:truncateBody] + "..."
-		}
+	notice.Body = fmt.Sprintf("From %s \n %s", notice.SourceID, notice.Body)
+
+	if len(notice.Body) > truncateBody {
+		notice.Body = notice.Body[:truncateBody] + "..."
+	}
+
+	if len(notice.Title) > truncateTitle {
+		notice.Title = notice.Title[:truncateTitle] + "..."
+	}
+
+	// Printf
+	notice.Body = fmt.Sprintf("%s\n[View on site](%s)", notice.Body, convertToSiteUrl(notice.ID))
+
+	embed := &discordgo.MessageEmbed{
+		Title:       notice.Title,
+		URL:         notice.URL,
+		Description: notice.Body,
+	}
+	if s, ok := salary.FromNotice(n); ok {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Salary", Value: s.String()})
+	}
+	if w, ok := workplace.FromNotice(n); ok {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Workplace", Value: w.String()})
+	}
+	if len(alsoOn) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Also on", Value: strings.Join(alsoOn, ", ")})
+	}
+
+	return embed
+}
+
+// SendList posts every notice, stopping early when ctx is done. alsoOn are
+// the other sources of each notice, keyed by its id.
+func SendList(ctx context.Context, bot *discordgo.Session, notices []*models.Notice, alsoOn map[string][]string) {
+	sendEmbeds(ctx, bot, notices, func(n *models.Notice) *discordgo.MessageEmbed {
+		return noticeEmbed(n, alsoOn[n.ID])
+	})
+}
+
+// updateEmbed formats a notice that was edited since it was sent.
+func updateEmbed(n *models.Notice) *discordgo.MessageEmbed {
+	embed := noticeEmbed(n, nil)
+	embed.Title = "Updated: " + embed.Title
+	return embed
+}
+
+// SendUpdates posts every notice that was edited, like SendList.
+func SendUpdates(ctx context.Context, bot *discordgo.Session, notices []*models.Notice) {
+	sendEmbeds(ctx, bot, notices, updateEmbed)
+}
 
-		if len(notice.Title) > truncateTitle {
-			notice.Title = notice.Title[:truncateTitle] + "..."
+func sendEmbeds(ctx context.Context, bot *discordgo.Session, notices []*models.Notice, embed func(*models.Notice) *discordgo.MessageEmbed) {
+	for i, n := range notices {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Stopped sending notices, %d left unsent: %v", len(notices)-i, err)
+			return
 		}
+		sendEmbed(ctx, bot, os.Getenv("JOBBYMCJOBFACE_HIRING_CHANNEL_ID"), embed(n))
+	}
+}
 
-		// Printf
-		notice.Body = fmt.Sprintf("%s\n[View on site](%s)", notice.Body, convertToSiteUrl(notice.ID))
+// reportMessage formats summary for the hiring channel. The first line tells
+// a run where every source worked from one where some, or all, of them
+// failed, and the sources and feeds that failed are listed under the counts.
+func reportMessage(summary Summary, at time.Time) string {
+	report := summary.Sources
+	date := at.Format("January 2, 2006 15:04:05")
+
+	var b strings.Builder
+	switch report.Status() {
+	case source.StatusOK:
+		fmt.Fprintf(&b, "Succesfully run script at: %s\n", date)
+	case source.StatusPartial:
+		fmt.Fprintf(&b, "Script partially failed at: %s (%s)\n", date, report.Summary())
+	default:
+		fmt.Fprintf(&b, "Script failed at: %s, no source could be fetched (%s)\n", date, report.Summary())
+	}
 
-		embed := &discordgo.MessageEmbed{
-			Title:       notice.Title,
-			URL:         notice.URL,
-			Description: notice.Body,
+	fmt.Fprintf(&b, "Notices matched: %d\nNew notices: %d\nDuplicates: %d\nUpdated notices: %d\nFailed to insert: %d\nRetried requests: %d\nFeeds not modified: %d\nRuntime: %v\nSite URL: https://workfindy.com/",
+		summary.Scraped, summary.New, summary.Duplicates, summary.Updated, summary.Failed, report.Retries(), report.NotModified(), summary.Runtime)
+
+	for _, result := range report.Results {
+		switch result.Status() {
+		case source.StatusOK:
+			continue
+		case source.StatusPartial:
+			fmt.Fprintf(&b, "\n%s %s:", result.Source, result.Status())
+		default:
+			fmt.Fprintf(&b, "\n%s %s: %v", result.Source, result.Status(), result.Err)
+			// The error of a failed source already joins those of its feeds
+			continue
 		}
+		for _, feed := range result.FailedFeeds() {
+			fmt.Fprintf(&b, "\n- %v", feed.Err)
+		}
+	}
 
-		sendEmbed(bot, os.Getenv("JOBBYMCJOBFACE_HIRING_CHANNEL_ID"), embed)
+	message := b.String()
+	if len(message) > maxMessageLength {
+		message = message[:maxMessageLength-3] + "..."
 	}
+	return message
 }
 
-func SendSuccessNotificatioin(bot *discordgo.Session, scrapedCount, newNoticeCount int, runtime time.Duration) error {
-	message := fmt.Sprintf("Succesfully run script at: %s\nNotices matched: %d\nNew notices: %d\nRuntime: %v\nSite URL: https://workfindy.com/",
-		time.Now().Format("January 2, 2006 15:04:05"), scrapedCount, newNoticeCount, runtime)
+// SendReport posts the summary of a run.
+func SendReport(ctx context.Context, bot *discordgo.Session, summary Summary) error {
+	message := reportMessage(summary, time.Now())
 
-	_, err := bot.ChannelMessageSend(os.Getenv("JOBBYMCJOBFACE_HIRING_CHANNEL_ID"), message)
+	_, err := bot.ChannelMessageSend(os.Getenv("JOBBYMCJOBFACE_HIRING_CHANNEL_ID"), message, discordgo.WithContext(ctx))
 	return err
 }
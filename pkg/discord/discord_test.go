@@ -0,0 +1,141 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+)
+
+func TestNoticeEmbed(t *testing.T) {
+	notice := &models.Notice{
+		ID:       "abc",
+		Title:    strings.Repeat("t", 300),
+		Body:     "<p>We are <b>hiring</b></p>" + strings.Repeat("b", 600),
+		URL:      "https://example.com/jobs/1",
+		SourceID: "HackerNews",
+	}
+
+	embed := noticeEmbed(notice, nil)
+
+	if embed.URL != notice.URL {
+		t.Errorf("Expected url %q, got %q", notice.URL, embed.URL)
+	}
+	if len(embed.Title) != 253 || !strings.HasSuffix(embed.Title, "...") {
+		t.Errorf("Expected title to be truncated to 250 characters, got %d", len(embed.Title))
+	}
+	if !strings.HasPrefix(embed.Description, "From HackerNews \n We are **hiring**") {
+		t.Errorf("Expected body to be converted to markdown, got %q", embed.Description[:40])
+	}
+	if !strings.HasSuffix(embed.Description, "...\n[View on site](https://workfindy.com/abc)") {
+		t.Errorf("Expected truncated body with a link to the site, got %q", embed.Description)
+	}
+
+	// The caller's notice is left alone
+	if len(notice.Title) != 300 {
+		t.Errorf("Expected notice to be unchanged")
+	}
+	if len(embed.Fields) != 0 {
+		t.Errorf("Expected no fields without a salary, got %v", embed.Fields)
+	}
+
+	min, max, currency, period := 50.0, 70.0, "USD", "hour"
+	notice.SalaryMin, notice.SalaryMax, notice.SalaryCurrency, notice.SalaryPeriod = &min, &max, &currency, &period
+	embed = noticeEmbed(notice, nil)
+	if len(embed.Fields) != 1 || embed.Fields[0].Name != "Salary" || embed.Fields[0].Value != "USD 50 – 70 an hour (about 104,000 – 145,600 a year)" {
+		t.Errorf("Expected a salary field, got %+v", embed.Fields)
+	}
+
+	kind, regions := "remote-region", "US"
+	notice.Workplace, notice.Regions = &kind, &regions
+	embed = noticeEmbed(notice, nil)
+	if len(embed.Fields) != 2 || embed.Fields[1].Name != "Workplace" || embed.Fields[1].Value != "Remote (US)" {
+		t.Errorf("Expected a workplace field, got %+v", embed.Fields)
+	}
+
+	embed = noticeEmbed(notice, []string{"Remotive", "RemoteOK"})
+	if len(embed.Fields) != 3 || embed.Fields[2].Name != "Also on" || embed.Fields[2].Value != "Remotive, RemoteOK" {
+		t.Errorf("Expected an also on field, got %+v", embed.Fields)
+	}
+}
+
+func TestUpdateEmbed(t *testing.T) {
+	notice := &models.Notice{ID: "abc", Title: "Go Developer", Body: "Now remote", SourceID: "HackerNews"}
+
+	embed := updateEmbed(notice)
+	if embed.Title != "Updated: Go Developer" || !strings.Contains(embed.Description, "Now remote") {
+		t.Errorf("Expected an updated embed, got %+v", embed)
+	}
+}
+
+func TestReportMessage(t *testing.T) {
+	at := time.Date(2025, time.February, 3, 12, 0, 0, 0, time.UTC)
+	feedErr := errors.New("failed to fetch https://example.com/feed: 502 Bad Gateway")
+	ok := source.Result{Source: "HackerNews", Retries: 2}
+	partial := source.Result{Source: "WeWorkRemotely", Feeds: []source.FeedResult{
+		{URL: "https://example.com/feed", Err: feedErr},
+		{URL: "https://example.com/other", Items: 3},
+	}}
+	failed := source.Result{Source: "Reddit", Err: errors.New("failed to load posts from reddit: 403 Forbidden")}
+
+	testCases := []struct {
+		name     string
+		results  []source.Result
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "Every source worked",
+			results:  []source.Result{ok},
+			contains: []string{"Succesfully run script at: February 3, 2025 12:00:00\n", "Retried requests: 2"},
+			excludes: []string{"HackerNews"},
+		},
+		{
+			name:    "Some feeds failed",
+			results: []source.Result{ok, partial, failed},
+			contains: []string{
+				"Script partially failed at: February 3, 2025 12:00:00 (1 ok, 1 partially failed, 1 failed)\n",
+				"\nWeWorkRemotely partially failed:\n- " + feedErr.Error(),
+				"\nReddit failed: failed to load posts from reddit: 403 Forbidden",
+			},
+			excludes: []string{"HackerNews", "example.com/other"},
+		},
+		{
+			name:     "Every source failed",
+			results:  []source.Result{failed},
+			contains: []string{"Script failed at: February 3, 2025 12:00:00, no source could be fetched (1 failed)\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			message := reportMessage(Summary{Sources: source.Report{Results: tc.results}}, at)
+
+			for _, s := range tc.contains {
+				if !strings.Contains(message, s) {
+					t.Errorf("Expected %q in the message, got %q", s, message)
+				}
+			}
+			for _, s := range tc.excludes {
+				if strings.Contains(message, s) {
+					t.Errorf("Expected no %q in the message, got %q", s, message)
+				}
+			}
+		})
+	}
+}
+
+func TestReportMessageLength(t *testing.T) {
+	var results []source.Result
+	for i := 0; i < 100; i++ {
+		results = append(results, source.Result{Source: "Reddit", Err: errors.New(strings.Repeat("e", 100))})
+	}
+
+	message := reportMessage(Summary{Sources: source.Report{Results: results}}, time.Now())
+	if len(message) != maxMessageLength || !strings.HasSuffix(message, "...") {
+		t.Errorf("Expected the message to be cut at %d characters, got %d", maxMessageLength, len(message))
+	}
+}
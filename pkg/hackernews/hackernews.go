@@ -1,6 +1,7 @@
 package hackernews
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"html"
@@ -17,22 +18,57 @@ import (
 )
 
 type Story struct {
-	Time int64  `json:"time"`
-	Text string `json:"text"`
-	Id   int    `json:"id"`
-	By   string `json:"by"`
-	Raw  string `json:"raw"`
+	Time    int64  `json:"time"`
+	Text    string `json:"text"`
+	Id      int    `json:"id"`
+	By      string `json:"by"`
+	Deleted bool   `json:"deleted"`
+	Dead    bool   `json:"dead"`
+	Raw     string `json:"raw"`
+}
+
+// ScrapeOptions controls which comments of a thread get fetched.
+type ScrapeOptions struct {
+	// Limit caps the number of newest comments fetched, zero fetches every comment
+	Limit int
+	// Workers bounds the number of comments fetched at once
+	Workers int
+	// Known holds the guids already stored for the thread's source, these are not fetched again
+	Known map[string]bool
+	// Skipped collects the guids of the comments that were fetched but left
+	// out, deleted, dead or too short, when it isn't nil
+	Skipped map[string]bool
+	// Client makes the requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+func (o ScrapeOptions) client() *http.Client {
+	if o.Client == nil {
+		return http.DefaultClient
+	}
+	return o.Client
 }
 
 type Parent struct {
-	Kids []int
-	Time int64
+	Id    int
+	Title string
+	Kids  []int
+	Time  int64
+}
+
+type User struct {
+	Id        string `json:"id"`
+	Submitted []int  `json:"submitted"`
 }
 
 var (
-	hnSourceName = "HackerNews"
-	currentId    = 42575537 // Automate getting this
-	toGet        = 30
+	apiBaseURL = "https://hacker-news.firebaseio.com/v0"
+	hiringUser = "whoishiring"
+	// whoishiring posts three threads a month, so the current one is always near the top
+	searchDepth = 12
+	cacheTTL    = 6 * time.Hour
+
+	DefaultScrapeOptions = ScrapeOptions{Limit: 30, Workers: 8}
 )
 
 func extractTitle(text string) (string, string) {
@@ -75,74 +111,156 @@ func extractTitle(text string) (string, string) {
 	return title, body
 }
 
-func getStory(id int, wg *sync.WaitGroup, ch chan<- Story) {
-	defer wg.Done()
-	storyResp, err := http.Get(fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json?print=pretty", id))
+func itemUrl(id int) string {
+	return fmt.Sprintf("%s/item/%d.json", apiBaseURL, id)
+}
+
+func userUrl(name string) string {
+	return fmt.Sprintf("%s/user/%s.json", apiBaseURL, name)
+}
+
+// get requests url, giving up when ctx is done.
+func get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	resp, err := get(ctx, client, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// findThread walks the items submitted by user, newest first, and returns the
+// first one whose title starts with titlePrefix.
+func findThread(ctx context.Context, client *http.Client, user string, titlePrefix string) (*Parent, error) {
+	var u User
+	if err := getJSON(ctx, client, userUrl(user), &u); err != nil {
+		return nil, fmt.Errorf("unable to get submissions of %s: %w", user, err)
+	}
+
+	submitted := append([]int(nil), u.Submitted...)
+	sort.Slice(submitted, func(i, j int) bool { return submitted[i] > submitted[j] })
+	if len(submitted) > searchDepth {
+		submitted = submitted[:searchDepth]
+	}
+
+	for _, id := range submitted {
+		var item Parent
+		if err := getJSON(ctx, client, itemUrl(id), &item); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			errorHandler.HandleErrorWithSection(err, fmt.Sprintf("Unable to get submission with ID of %d", id), "HackerNews")
+			continue
+		}
+		if strings.HasPrefix(item.Title, titlePrefix) {
+			return &item, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no thread titled %q found in the latest %d submissions of %s", titlePrefix, len(submitted), user)
+}
+
+func getStory(ctx context.Context, client *http.Client, id int) (*Story, error) {
+	storyResp, err := get(ctx, client, itemUrl(id))
 	if err != nil {
-		errorHandler.HandleErrorWithSection(err, fmt.Sprintf("Unable to get story with ID of %d\n", id), "HackerNews")
-		return
+		return nil, fmt.Errorf("unable to get story with ID of %d: %w", id, err)
 	}
 	defer storyResp.Body.Close()
 
+	if storyResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s for story with ID of %d", storyResp.Status, id)
+	}
+
 	storyBytes, err := io.ReadAll(storyResp.Body)
 	if err != nil {
-		errorHandler.HandleErrorWithSection(err, fmt.Sprintf("Unable to convert response with ID of %d to string\n", id), "HackerNews")
-		return
+		return nil, fmt.Errorf("unable to read response with ID of %d: %w", id, err)
 	}
 
 	var story Story
 	err = json.Unmarshal(storyBytes, &story)
 	if err != nil {
-		errorHandler.HandleErrorWithSection(err, fmt.Sprintf("Unable to decode response with ID of %d\n", id), "HackerNews")
-		return
+		return nil, fmt.Errorf("unable to decode response with ID of %d: %w", id, err)
 	}
 
 	story.Raw = string(storyBytes)
-	ch <- story
+	return &story, nil
 }
 
-func ScrapeCurrentWhoIsHiringPosts() []*models.Notice {
-	var notices []*models.Notice
-	var parent Parent
-
-	resp, err := http.Get(fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json?print=pretty", currentId))
-	errorHandler.HandleErrorWithSection(err, "Unable to get current who is hiring post", "HackerNews")
-
-	defer resp.Body.Close()
-
-	if err := json.NewDecoder(resp.Body).Decode(&parent); err != nil {
-		errorHandler.HandleErrorWithSection(err, "Unable to decode response for current who is hiring post", "HackerNews")
-		return nil
+// getStories fetches ids using at most workers concurrent requests. Stories
+// that failed to load, were deleted or are dead are left out, the ids of the
+// deleted and dead ones are returned as skipped. No more stories are
+// requested once ctx is done.
+func getStories(ctx context.Context, client *http.Client, ids []int, workers int) ([]Story, []int) {
+	if workers < 1 {
+		workers = 1
 	}
 
-	// Sort by ID because I think newer posts are have higher IDs
-	kidsCopy := append([]int(nil), parent.Kids...)
-	sort.Slice(kidsCopy, func(i, j int) bool { return kidsCopy[i] > kidsCopy[j] })
-	if toGet > len(kidsCopy) {
-		toGet = len(kidsCopy)
-	}
-	storyChannel := make(chan Story, len(kidsCopy[:toGet]))
+	idCh := make(chan int)
+	storyCh := make(chan Story, len(ids))
+	skippedCh := make(chan int, len(ids))
 
 	var wg sync.WaitGroup
-	wg.Add(len(kidsCopy[:toGet]))
+	wg.Add(workers)
 
-	for _, id := range kidsCopy[:toGet] {
-		go getStory(id, &wg, storyChannel)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for id := range idCh {
+				story, err := getStory(ctx, client, id)
+				if err != nil {
+					if ctx.Err() != nil {
+						continue
+					}
+					errorHandler.HandleErrorWithSection(err, "Unable to get story", "HackerNews")
+					continue
+				}
+				// Firebase returns null for items that don't exist
+				if story.Id == 0 || story.Deleted || story.Dead {
+					skippedCh <- id
+					continue
+				}
+				storyCh <- *story
+			}
+		}()
 	}
 
-	wg.Wait()
-	close(storyChannel)
-
-	for story := range storyChannel {
-		s := StoryToNotice(story)
-		if FilterOutLessThan10Len(s) {
-			notices = append(notices, &s)
+feed:
+	for _, id := range ids {
+		select {
+		case idCh <- id:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(idCh)
 
-	fmt.Printf("Fetched %d items from HackerNews\n", len(notices))
+	wg.Wait()
+	close(storyCh)
+	close(skippedCh)
 
-	return notices
+	stories := make([]Story, 0, len(ids))
+	for story := range storyCh {
+		stories = append(stories, story)
+	}
+	var skipped []int
+	for id := range skippedCh {
+		skipped = append(skipped, id)
+	}
+
+	return stories, skipped
 }
 
 func urlify(id int) string {
@@ -153,10 +271,10 @@ func authorUrlify(authorName string) string {
 	return fmt.Sprintf("https://news.ycombinator.com/user?id=%s", authorName)
 }
 
-func StoryToNotice(story Story) models.Notice {
-	t := time.Unix(story.Time, 0).UTC()
-	title, body := extractTitle(story.Text)
-	return models.Notice{
+func (t *Thread) StoryToNotice(story Story) models.Notice {
+	published := time.Unix(story.Time, 0).UTC()
+	title, body := t.ExtractTitle(story.Text)
+	notice := models.Notice{
 		ID:            uuid.New().String(),
 		Title:         title,
 		Body:          body,
@@ -164,11 +282,17 @@ func StoryToNotice(story Story) models.Notice {
 		AuthorName:    story.By,
 		AuthorURL:     authorUrlify(story.By),
 		ImageURL:      nil,
-		SourceID:      hnSourceName,
+		SourceID:      t.SourceName,
 		Raw:           story.Raw,
 		Guid:          fmt.Sprint(story.Id),
-		PublishedDate: &t,
+		PublishedDate: &published,
 	}
+
+	if t.ParseDetails != nil {
+		t.ParseDetails(&notice, story.Text)
+	}
+
+	return notice
 }
 
 func FilterOutLessThan10Len(notice models.Notice) bool {
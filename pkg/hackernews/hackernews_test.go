@@ -0,0 +1,381 @@
+package hackernews
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/cassette"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+)
+
+type fakeFirebase struct {
+	users    map[string]any
+	items    map[string]any
+	userHits atomic.Int32
+	// hang holds the items that are never answered, the request only ends
+	// when the client gives up
+	hang map[string]bool
+}
+
+func (f *fakeFirebase) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v0/"), ".json")
+	kind, id, _ := strings.Cut(path, "/")
+
+	var body any
+	switch kind {
+	case "user":
+		f.userHits.Add(1)
+		body = f.users[id]
+	case "item":
+		if f.hang[id] {
+			<-r.Context().Done()
+			return
+		}
+		body = f.items[id]
+	}
+
+	// Firebase answers unknown paths with a literal null
+	json.NewEncoder(w).Encode(body)
+}
+
+func startFakeFirebase(t *testing.T, f *fakeFirebase) {
+	t.Helper()
+	server := httptest.NewServer(f)
+
+	oldBase := apiBaseURL
+	apiBaseURL = server.URL + "/v0"
+	forgetThreads()
+
+	t.Cleanup(func() {
+		server.Close()
+		apiBaseURL = oldBase
+		forgetThreads()
+	})
+}
+
+func forgetThreads() {
+	for _, thread := range Threads {
+		thread.forget()
+	}
+}
+
+func newFakeFirebase() *fakeFirebase {
+	return &fakeFirebase{
+		users: map[string]any{
+			"whoishiring": map[string]any{"id": "whoishiring", "submitted": []int{300, 299, 298, 200, 199, 198}},
+		},
+		items: map[string]any{
+			"300":  map[string]any{"id": 300, "title": "Ask HN: Freelancer? Seeking freelancer? (February 2025)"},
+			"299":  map[string]any{"id": 299, "title": "Ask HN: Who wants to be hired? (February 2025)"},
+			"298":  map[string]any{"id": 298, "title": "Ask HN: Who is hiring? (February 2025)", "kids": []int{1001, 1002}},
+			"200":  map[string]any{"id": 200, "title": "Ask HN: Who is hiring? (January 2025)", "kids": []int{900}},
+			"1001": map[string]any{"id": 1001, "by": "acme", "time": 1738400000, "text": "Acme Corp | Backend Engineer | REMOTE<p>We are hiring Go developers."},
+			"1002": map[string]any{"id": 1002, "by": "widgets", "time": 1738400100, "text": "Widgets Inc | SRE | Berlin<p>Come build widgets with us."},
+		},
+	}
+}
+
+func TestCurrentThreadId(t *testing.T) {
+	f := newFakeFirebase()
+	startFakeFirebase(t, f)
+
+	testCases := []struct {
+		thread   *Thread
+		expected int
+	}{
+		{thread: WhoIsHiring, expected: 298},
+		{thread: WhoWantsToBeHired, expected: 299},
+		{thread: Freelancer, expected: 300},
+	}
+
+	for _, tc := range testCases {
+		id, err := tc.thread.currentId(context.Background(), http.DefaultClient)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if id != tc.expected {
+			t.Errorf("Expected %s thread %d, got %d", tc.thread.SourceName, tc.expected, id)
+		}
+	}
+
+	if _, err := WhoIsHiring.currentId(context.Background(), http.DefaultClient); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hits := f.userHits.Load(); hits != int32(len(testCases)) {
+		t.Errorf("Expected resolved thread to be cached, user endpoint was hit %d times", hits)
+	}
+}
+
+func TestCurrentThreadIdNotFound(t *testing.T) {
+	f := newFakeFirebase()
+	f.users["whoishiring"] = map[string]any{"id": "whoishiring", "submitted": []int{300, 299}}
+	startFakeFirebase(t, f)
+
+	if _, err := WhoIsHiring.currentId(context.Background(), http.DefaultClient); err == nil {
+		t.Errorf("Expected error but got none")
+	}
+}
+
+func TestThreadSourceFetch(t *testing.T) {
+	startFakeFirebase(t, newFakeFirebase())
+
+	s := &threadSource{thread: WhoIsHiring}
+	notices, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(notices) != 2 {
+		t.Fatalf("Expected 2 notices, got %d", len(notices))
+	}
+
+	// Comments stored within the edit window are fetched again
+	s.known = func(ctx context.Context, sourceName string) (map[string]bool, error) {
+		return map[string]bool{"1001": true, "1002": true}, nil
+	}
+	s.recent = func(ctx context.Context, sourceName string, since time.Time) (map[string]bool, error) {
+		if time.Since(since) < EditWindow {
+			t.Errorf("Expected the comments since %v ago, got %v", EditWindow, time.Since(since))
+		}
+		return map[string]bool{"1002": true}, nil
+	}
+	again, err := s.Fetch(context.Background())
+	if err != nil || len(again) != 1 || again[0].Guid != "1002" {
+		t.Errorf("Expected only the recent comment to be fetched again, got %v: %v", again, err)
+	}
+
+	for _, notice := range notices {
+		if notice.SourceID != WhoIsHiring.SourceName {
+			t.Errorf("Expected source %s, got %s", WhoIsHiring.SourceName, notice.SourceID)
+		}
+		if notice.Guid != "1001" && notice.Guid != "1002" {
+			t.Errorf("Unexpected notice with guid %s", notice.Guid)
+		}
+	}
+}
+
+func TestThreadTypes(t *testing.T) {
+	// The config accepts exactly the threads a source can be built for
+	if len(threadTypes) != len(config.HackerNewsThreads) {
+		t.Errorf("Expected %d threads, got %d", len(config.HackerNewsThreads), len(threadTypes))
+	}
+	for _, thread := range config.HackerNewsThreads {
+		if _, err := newSource(config.Source{Name: "HackerNews", Type: "hackernews", Thread: thread}, source.Deps{}); err != nil {
+			t.Errorf("Unexpected error for thread %s: %v", thread, err)
+		}
+	}
+}
+
+func TestThreadSourceSkipped(t *testing.T) {
+	f := newFakeFirebase()
+	f.items["298"] = map[string]any{"id": 298, "title": "Ask HN: Who is hiring? (February 2025)", "kids": []int{1001, 1002, 1003, 1004, 1005}}
+	f.items["1003"] = map[string]any{"id": 1003, "by": "short", "time": 1738400200, "text": "Hiring"}
+	f.items["1004"] = map[string]any{"id": 1004, "deleted": true, "time": 1738400300}
+	f.items["1005"] = map[string]any{"id": 1005, "dead": true, "by": "spam", "time": 1738400400, "text": "Totally legit job board, click here"}
+	startFakeFirebase(t, f)
+
+	stored := map[string]bool{}
+	s := &threadSource{
+		thread: WhoIsHiring,
+		opts:   ScrapeOptions{Limit: 2, Workers: 1},
+		known: func(ctx context.Context, sourceName string) (map[string]bool, error) {
+			known := map[string]bool{}
+			for guid := range stored {
+				known[guid] = true
+			}
+			return known, nil
+		},
+	}
+
+	// The comments that are never stored don't take up the limit again
+	var runs [][]string
+	for range 3 {
+		notices, err := s.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var guids []string
+		for _, notice := range notices {
+			stored[notice.Guid] = true
+			guids = append(guids, notice.Guid)
+		}
+		sort.Strings(guids)
+		runs = append(runs, guids)
+	}
+
+	expected := [][]string{nil, {"1002"}, {"1001"}}
+	for i := range expected {
+		if strings.Join(runs[i], ",") != strings.Join(expected[i], ",") {
+			t.Errorf("Expected run %d to fetch %v, got %v", i+1, expected[i], runs[i])
+		}
+	}
+}
+
+func TestScrapeWhoIsHiringPosts(t *testing.T) {
+	f := newFakeFirebase()
+	f.items["298"] = map[string]any{"id": 298, "title": "Ask HN: Who is hiring? (February 2025)", "kids": []int{1001, 1002, 1003, 1004, 1005}}
+	f.items["1003"] = map[string]any{"id": 1003, "deleted": true, "time": 1738400200}
+	f.items["1004"] = map[string]any{"id": 1004, "dead": true, "by": "spam", "time": 1738400300, "text": "Totally legit job board, click here"}
+	f.items["1005"] = map[string]any{"id": 1005, "by": "gadgets", "time": 1738400400, "text": "Gadgets Ltd | Frontend Engineer | ONSITE<p>React and TypeScript."}
+	startFakeFirebase(t, f)
+
+	testCases := []struct {
+		name     string
+		opts     ScrapeOptions
+		expected []string
+	}{
+		{
+			name:     "Fetch every comment, skipping deleted and dead ones",
+			opts:     ScrapeOptions{Workers: 2},
+			expected: []string{"1001", "1002", "1005"},
+		},
+		{
+			name:     "Skip comments that are already stored",
+			opts:     ScrapeOptions{Workers: 2, Known: map[string]bool{"1001": true, "1005": true}},
+			expected: []string{"1002"},
+		},
+		{
+			name:     "Limit to the newest comments",
+			opts:     ScrapeOptions{Limit: 2, Workers: 1},
+			expected: []string{"1005"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notices, err := WhoIsHiring.Scrape(context.Background(), tc.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := map[string]bool{}
+			for _, notice := range notices {
+				got[notice.Guid] = true
+			}
+
+			if len(got) != len(tc.expected) {
+				t.Errorf("Expected %d notices, got %d", len(tc.expected), len(got))
+			}
+			for _, guid := range tc.expected {
+				if !got[guid] {
+					t.Errorf("Expected notice with guid %s", guid)
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeDeadline(t *testing.T) {
+	f := newFakeFirebase()
+	f.hang = map[string]bool{"1002": true}
+	startFakeFirebase(t, f)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// A comment that never loads fails the scrape instead of blocking it
+	_, err := WhoIsHiring.Scrape(ctx, ScrapeOptions{Workers: 2})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestExtractTitle(t *testing.T) {
+	testCases := []struct {
+		name     string
+		extract  TitleExtractor
+		text     string
+		expected string
+	}{
+		{
+			name:     "Seeker template",
+			extract:  extractSeekerTitle,
+			text:     "Location: Berlin, Germany<p>Remote: Yes<p>Willing to relocate: No<p>Technologies: Go, Rust, PostgreSQL<p>Résumé&#x2F;CV: <a href=\"https:&#x2F;&#x2F;example.com\">https:&#x2F;&#x2F;example.com</a><p>Email: me@example.com",
+			expected: "Seeking work | Go, Rust, PostgreSQL | Berlin, Germany | Remote",
+		},
+		{
+			name:     "Seeker without template falls back",
+			extract:  extractSeekerTitle,
+			text:     "Backend engineer looking for work<p>Ping me at me@example.com",
+			expected: "Backend engineer looking for work",
+		},
+		{
+			name:     "Freelancer with pipe header",
+			extract:  extractFreelanceTitle,
+			text:     "SEEKING WORK | Remote | React, Node.js<p>I build web apps.",
+			expected: "SEEKING WORK | Remote | React, Node.js",
+		},
+		{
+			name:     "Freelancer with template",
+			extract:  extractFreelanceTitle,
+			text:     "SEEKING WORK<p>Location: Lisbon<p>Remote: Yes<p>Technologies: Python, Django",
+			expected: "SEEKING WORK | Python, Django | Lisbon | Remote",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			title, _ := tc.extract(tc.text)
+			if title != tc.expected {
+				t.Errorf("Expected title %q, got %q", tc.expected, title)
+			}
+		})
+	}
+}
+
+func TestFetchCassette(t *testing.T) {
+	forgetThreads()
+	t.Cleanup(forgetThreads)
+
+	recorder := cassette.Load(t, "whoishiring")
+	s, err := newSource(config.Source{Name: "HackerNews", Type: "hackernews", Thread: "hiring"}, source.Deps{HTTPClient: recorder.Client()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	notices, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Slice(notices, func(i, j int) bool { return notices[i].Guid > notices[j].Guid })
+
+	// The deleted comment is skipped
+	expected := []struct {
+		guid    string
+		title   string
+		company string
+		remote  string
+	}{
+		{"42931001", "Senior Backend Engineer at Acme Payments", "Acme Payments", "true"},
+		{"42930877", "Staff Rust Engineer at Quill", "Quill", "false"},
+		{"42929988", "We're hiring ML engineers at Lumen Labs, London or remote in the UK.", "", "nil"},
+	}
+	if len(notices) != len(expected) {
+		t.Fatalf("Expected %d notices, got %d", len(expected), len(notices))
+	}
+
+	for i, e := range expected {
+		notice := notices[i]
+		if notice.Guid != e.guid || notice.Title != e.title {
+			t.Errorf("Expected %s %q, got %s %q", e.guid, e.title, notice.Guid, notice.Title)
+		}
+		company := ""
+		if notice.Company != nil {
+			company = *notice.Company
+		}
+		if company != e.company || fmtBool(notice.Remote) != e.remote {
+			t.Errorf("Unexpected details of %s: company %q, remote %s", e.guid, company, fmtBool(notice.Remote))
+		}
+		if notice.URL != "https://news.ycombinator.com/item?id="+e.guid || notice.SourceID != "HackerNews" {
+			t.Errorf("Unexpected notice %+v", notice)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package hackernews
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// Header holds the fields of the pipe delimited first line most hiring posts
+// start with, e.g. "Acme | Senior Engineer | Berlin | REMOTE | $150k".
+type Header struct {
+	Line     string
+	Company  string
+	Role     string
+	Location string
+	Remote   *bool
+	Salary   string
+}
+
+var (
+	headerSeparatorRe = regexp.MustCompile(`\s+[|•]\s+|\s*\|\s*`)
+	urlRe             = regexp.MustCompile(`(?i)\(?\s*(https?://|www\.)\S*\s*\)?`)
+	domainRe          = regexp.MustCompile(`(?i)^[\w-]+(\.[\w-]+)*\.(com|io|ai|co|dev|org|net|app|tech)(/\S*)?$`)
+	salaryRe          = regexp.MustCompile(`(?i)[$€£]\s?\d|\d+\s?k\b|\b(usd|eur|gbp|cad|aud|chf)\b|\bequity\b|\bsalary\b|/\s?(yr|year|hr|hour)\b`)
+	remoteRe          = regexp.MustCompile(`(?i)\bremote\b|\bwfh\b|\banywhere\b`)
+	onsiteRe          = regexp.MustCompile(`(?i)\bon-?site\b|\bin[- ]office\b|\bin[- ]person\b|\bhybrid\b`)
+	employmentRe      = regexp.MustCompile(`(?i)^(full[- ]?time|part[- ]?time|contract(or)?|freelance|intern(ship)?s?|permanent|ft|pt|visa( sponsorship)?( available)?|[, /&]|and)+$`)
+	roleRe            = regexp.MustCompile(`(?i)engineer|developer|programmer|designer|manager|scientist|\blead\b|architect|\bsre\b|devops|\bproduct\b|analyst|\bhead of\b|director|\bcto\b|founding|\bstaff\b|researcher|specialist|administrator|\bqa\b|\bml\b|consultant|technician|positions|roles|\bvarious\b|multiple`)
+	remoteOnlyRe      = regexp.MustCompile(`(?i)^(fully |100% )?(remote|on-?site|hybrid|wfh)( only| ok| friendly| first)?$`)
+)
+
+// headerLine returns the first paragraph of a comment as plain text and the
+// HTML that follows it.
+func headerLine(text string) (string, string) {
+	line, rest, _ := strings.Cut(text, "<p>")
+	line = tagRe.ReplaceAllString(line, "")
+	line = html.UnescapeString(line)
+	return strings.TrimSpace(line), rest
+}
+
+// ParseHeader splits the first line of a hiring post into its fields. It
+// returns false when the line doesn't follow the pipe delimited format.
+func ParseHeader(text string) (*Header, bool) {
+	line, _ := headerLine(text)
+	if !strings.Contains(line, "|") {
+		return nil, false
+	}
+
+	var segments []string
+	for _, segment := range headerSeparatorRe.Split(line, -1) {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) < 3 {
+		return nil, false
+	}
+
+	header := &Header{
+		Line:    line,
+		Company: cleanCompany(segments[0]),
+	}
+
+	var unknown []string
+	for _, segment := range segments[1:] {
+		isRemote := remoteRe.MatchString(segment)
+		isOnsite := !isRemote && onsiteRe.MatchString(segment)
+		if isRemote {
+			remote := true
+			header.Remote = &remote
+		} else if isOnsite && header.Remote == nil {
+			remote := false
+			header.Remote = &remote
+		}
+
+		switch {
+		case urlRe.ReplaceAllString(segment, "") == "" || domainRe.MatchString(segment):
+			// Links to the company site or careers page
+		case employmentRe.MatchString(segment):
+		case remoteOnlyRe.MatchString(segment):
+		case roleRe.MatchString(segment):
+			header.Role = joinField(header.Role, segment)
+		case salaryRe.MatchString(segment):
+			header.Salary = joinField(header.Salary, segment)
+		case isRemote || isOnsite:
+			// e.g. "Remote (US only)" or "NYC, Hybrid"
+			if header.Location == "" {
+				header.Location = segment
+			}
+		default:
+			unknown = append(unknown, segment)
+		}
+	}
+
+	// Whatever is left over is most likely the role first, then the location
+	for _, segment := range unknown {
+		if header.Role == "" {
+			header.Role = segment
+		} else if header.Location == "" {
+			header.Location = segment
+		}
+	}
+
+	if header.Company == "" {
+		return nil, false
+	}
+
+	return header, true
+}
+
+func cleanCompany(segment string) string {
+	segment = urlRe.ReplaceAllString(segment, "")
+	segment = strings.Trim(segment, " ()[]-–:")
+	return strings.TrimSpace(segment)
+}
+
+func joinField(field string, segment string) string {
+	if field == "" {
+		return segment
+	}
+	return field + ", " + segment
+}
+
+// Title formats the header as "Role at Company".
+func (h *Header) Title() string {
+	if h.Role == "" {
+		return h.Company
+	}
+	return h.Role + " at " + h.Company
+}
+
+// Apply copies the parsed fields onto notice.
+func (h *Header) Apply(notice *models.Notice) {
+	notice.Company = optional(h.Company)
+	notice.Role = optional(h.Role)
+	notice.Location = optional(h.Location)
+	notice.Remote = h.Remote
+	notice.Salary = optional(h.Salary)
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func extractHiringTitle(text string) (string, string) {
+	header, ok := ParseHeader(text)
+	if !ok {
+		return extractTitle(text)
+	}
+
+	_, rest := headerLine(text)
+	return header.Title(), html.UnescapeString(rest)
+}
+
+func parseHiringDetails(notice *models.Notice, text string) {
+	if header, ok := ParseHeader(text); ok {
+		header.Apply(notice)
+	}
+}
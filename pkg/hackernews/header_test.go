@@ -0,0 +1,152 @@
+package hackernews
+
+import (
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	remote := true
+	onsite := false
+
+	// Samples follow the markup of comments in the monthly hiring threads
+	testCases := []struct {
+		name     string
+		text     string
+		ok       bool
+		expected Header
+	}{
+		{
+			name: "Company, role, location, remote and salary",
+			text: "Acme Corp | Senior Backend Engineer | Berlin, Germany | REMOTE | $150k-$190k<p>We build payment rails in Go.",
+			ok:   true,
+			expected: Header{
+				Company:  "Acme Corp",
+				Role:     "Senior Backend Engineer",
+				Location: "Berlin, Germany",
+				Remote:   &remote,
+				Salary:   "$150k-$190k",
+			},
+		},
+		{
+			name: "Company link and full-time marker",
+			text: "Tandem (<a href=\"https:&#x2F;&#x2F;tandem.example\" rel=\"nofollow\">https:&#x2F;&#x2F;tandem.example</a>) | Staff Software Engineer | Full-time | ONSITE | New York, NY<p>Apply at <a href=\"https:&#x2F;&#x2F;tandem.example&#x2F;jobs\">tandem.example&#x2F;jobs</a>",
+			ok:   true,
+			expected: Header{
+				Company:  "Tandem",
+				Role:     "Staff Software Engineer",
+				Location: "New York, NY",
+				Remote:   &onsite,
+			},
+		},
+		{
+			name: "Remote with region and salary in euros",
+			text: "Widgets GmbH | Frontend Developer (React) | Remote (EU only) | €70.000 – 85.000<p>Small team, big ambitions.",
+			ok:   true,
+			expected: Header{
+				Company:  "Widgets GmbH",
+				Role:     "Frontend Developer (React)",
+				Location: "Remote (EU only)",
+				Remote:   &remote,
+				Salary:   "€70.000 – 85.000",
+			},
+		},
+		{
+			name: "Hybrid location and multiple roles",
+			text: "Lumen Labs | Multiple roles: ML Engineer, Data Scientist | London, UK (Hybrid) | lumenlabs.io<p>We are hiring across the board.",
+			ok:   true,
+			expected: Header{
+				Company:  "Lumen Labs",
+				Role:     "Multiple roles: ML Engineer, Data Scientist",
+				Location: "London, UK (Hybrid)",
+				Remote:   &onsite,
+			},
+		},
+		{
+			name: "Role and location without keywords",
+			text: "Quill | Rust | San Francisco | $180k + equity<p>Compilers.",
+			ok:   true,
+			expected: Header{
+				Company:  "Quill",
+				Role:     "Rust",
+				Location: "San Francisco",
+				Salary:   "$180k + equity",
+			},
+		},
+		{
+			name: "Escaped characters",
+			text: "Smith &amp; Jones | Site Reliability Engineer | Toronto | REMOTE OK<p>",
+			ok:   true,
+			expected: Header{
+				Company:  "Smith & Jones",
+				Role:     "Site Reliability Engineer",
+				Location: "Toronto",
+				Remote:   &remote,
+			},
+		},
+		{
+			name: "Prose instead of a header",
+			text: "We&#x27;re hiring engineers at Acme! We are a remote-first company building tools.<p>Email jobs@acme.example",
+			ok:   false,
+		},
+		{
+			name: "Too few segments",
+			text: "Acme | Remote<p>Ping us.",
+			ok:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header, ok := ParseHeader(tc.text)
+			if ok != tc.ok {
+				t.Fatalf("Expected ok to be %v, got %v", tc.ok, ok)
+			}
+			if !ok {
+				return
+			}
+
+			if header.Company != tc.expected.Company {
+				t.Errorf("Expected company %q, got %q", tc.expected.Company, header.Company)
+			}
+			if header.Role != tc.expected.Role {
+				t.Errorf("Expected role %q, got %q", tc.expected.Role, header.Role)
+			}
+			if header.Location != tc.expected.Location {
+				t.Errorf("Expected location %q, got %q", tc.expected.Location, header.Location)
+			}
+			if header.Salary != tc.expected.Salary {
+				t.Errorf("Expected salary %q, got %q", tc.expected.Salary, header.Salary)
+			}
+			if (header.Remote == nil) != (tc.expected.Remote == nil) ||
+				(header.Remote != nil && *header.Remote != *tc.expected.Remote) {
+				t.Errorf("Expected remote %v, got %v", fmtBool(tc.expected.Remote), fmtBool(header.Remote))
+			}
+		})
+	}
+}
+
+func TestExtractHiringTitle(t *testing.T) {
+	title, body := extractHiringTitle("Acme Corp | Backend Engineer | REMOTE<p>We are hiring Go developers.")
+	if title != "Backend Engineer at Acme Corp" {
+		t.Errorf("Unexpected title %q", title)
+	}
+	if body != "We are hiring Go developers." {
+		t.Errorf("Unexpected body %q", body)
+	}
+
+	// Falls back to the old heuristic when there is no header
+	title, _ = extractHiringTitle("Looking for engineers<p>Details to follow")
+	if title != "Looking for engineers" {
+		t.Errorf("Unexpected fallback title %q", title)
+	}
+}
+
+func fmtBool(b *bool) string {
+	if b == nil {
+		return "nil"
+	}
+	if *b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,164 @@
+package hackernews
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+)
+
+// EditWindow is how long Hacker News lets a comment be edited. The comments
+// stored within it are fetched again so their edits are saved.
+const EditWindow = 2 * time.Hour
+
+type threadSource struct {
+	thread *Thread
+	opts   ScrapeOptions
+	known  func(ctx context.Context, sourceName string) (map[string]bool, error)
+	recent func(ctx context.Context, sourceName string, since time.Time) (map[string]bool, error)
+
+	// skipped holds the comments fetched but never stored, deleted, dead or
+	// too short. They are treated as known so they don't take the place of
+	// new comments under the limit on every run.
+	mu      sync.Mutex
+	skipped map[string]bool
+}
+
+// threadTypes maps the thread setting of a configured source to its thread.
+var threadTypes = map[string]*Thread{
+	"hiring":    WhoIsHiring,
+	"seekers":   WhoWantsToBeHired,
+	"freelance": Freelancer,
+}
+
+func init() {
+	source.Register("hackernews", newSource)
+}
+
+func newSource(cfg config.Source, deps source.Deps) (source.Source, error) {
+	template, ok := threadTypes[cfg.Thread]
+	if !ok {
+		return nil, fmt.Errorf("unknown thread %q, expected one of %s", cfg.Thread, strings.Join(config.HackerNewsThreads, ", "))
+	}
+
+	thread := template
+	if cfg.Name != template.SourceName {
+		thread = &Thread{
+			SourceName:   cfg.Name,
+			Description:  template.Description,
+			TitlePrefix:  template.TitlePrefix,
+			ExtractTitle: template.ExtractTitle,
+			ParseDetails: template.ParseDetails,
+		}
+	}
+
+	opts := ScrapeOptions{Limit: cfg.Limit, Workers: DefaultScrapeOptions.Workers, Client: deps.HTTPClient}
+	if cfg.Workers > 0 {
+		opts.Workers = cfg.Workers
+	}
+
+	return &threadSource{thread: thread, opts: opts, known: deps.Known, recent: deps.Recent}, nil
+}
+
+func (s *threadSource) Name() string {
+	return s.thread.SourceName
+}
+
+func (s *threadSource) Description() string {
+	return s.thread.Description
+}
+
+func (s *threadSource) Homepage() string {
+	return Homepage
+}
+
+// Fetch gets every comment that isn't stored yet, up to the configured limit.
+// Only the latest comments are fetched if the stored guids can't be looked up.
+// The thread is reported as the only feed of the source.
+func (s *threadSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	notices, err := s.scrape(ctx)
+	feed := source.FeedResult{
+		Source:   s.thread.SourceName,
+		URL:      Homepage,
+		Items:    len(notices),
+		Duration: time.Since(startTime),
+		Err:      err,
+	}
+	if err := source.ReportFeeds(ctx, []source.FeedResult{feed}); err != nil {
+		return nil, err
+	}
+
+	return notices, nil
+}
+
+func (s *threadSource) scrape(ctx context.Context) ([]*models.Notice, error) {
+	opts := s.opts
+	if s.known == nil {
+		return s.scrapeWith(ctx, opts)
+	}
+
+	known, err := s.known(ctx, s.thread.SourceName)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errorHandler.HandleErrorWithSection(err, "Failed to get stored guids, fetching latest comments only", s.thread.SourceName)
+		if opts.Limit == 0 {
+			opts.Limit = DefaultScrapeOptions.Limit
+		}
+		return s.scrapeWith(ctx, opts)
+	}
+
+	if s.recent != nil {
+		recent, err := s.recent(ctx, s.thread.SourceName, time.Now().Add(-EditWindow))
+		if err != nil {
+			errorHandler.HandleErrorWithSection(err, "Failed to get recent guids, not checking them for edits", s.thread.SourceName)
+		}
+		for guid := range recent {
+			delete(known, guid)
+		}
+	}
+
+	opts.Known = known
+	return s.scrapeWith(ctx, opts)
+}
+
+// scrapeWith scrapes the thread without the comments skipped on earlier
+// runs, and remembers the ones skipped on this one.
+func (s *threadSource) scrapeWith(ctx context.Context, opts ScrapeOptions) ([]*models.Notice, error) {
+	s.mu.Lock()
+	known := make(map[string]bool, len(opts.Known)+len(s.skipped))
+	for guid := range opts.Known {
+		known[guid] = true
+	}
+	for guid := range s.skipped {
+		known[guid] = true
+	}
+	s.mu.Unlock()
+
+	opts.Known = known
+	opts.Skipped = map[string]bool{}
+	notices, err := s.thread.Scrape(ctx, opts)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.skipped == nil {
+		s.skipped = map[string]bool{}
+	}
+	for guid := range opts.Skipped {
+		s.skipped[guid] = true
+	}
+
+	return notices, err
+}
@@ -0,0 +1,234 @@
+package hackernews
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// TitleExtractor splits the text of a comment into a title and a body.
+type TitleExtractor func(text string) (string, string)
+
+// DetailsParser fills in the structured fields of a notice from the text of
+// a comment.
+type DetailsParser func(notice *models.Notice, text string)
+
+// Thread is one of the monthly threads posted by whoishiring. Each thread is
+// stored as its own source.
+type Thread struct {
+	SourceName   string
+	Description  string
+	TitlePrefix  string
+	ExtractTitle TitleExtractor
+	ParseDetails DetailsParser
+
+	mu         sync.Mutex
+	resolvedId int
+	resolvedAt time.Time
+}
+
+var (
+	WhoIsHiring = &Thread{
+		SourceName:   "HackerNews",
+		Description:  "Hacker News monthly Who is hiring? thread",
+		TitlePrefix:  "Ask HN: Who is hiring?",
+		ExtractTitle: extractHiringTitle,
+		ParseDetails: parseHiringDetails,
+	}
+	WhoWantsToBeHired = &Thread{
+		SourceName:   "HackerNewsSeekers",
+		Description:  "Hacker News monthly Who wants to be hired? thread",
+		TitlePrefix:  "Ask HN: Who wants to be hired?",
+		ExtractTitle: extractSeekerTitle,
+	}
+	Freelancer = &Thread{
+		SourceName:   "HackerNewsFreelance",
+		Description:  "Hacker News monthly Freelancer? Seeking freelancer? thread",
+		TitlePrefix:  "Ask HN: Freelancer? Seeking freelancer?",
+		ExtractTitle: extractFreelanceTitle,
+	}
+
+	Threads = []*Thread{WhoIsHiring, WhoWantsToBeHired, Freelancer}
+
+	Homepage = "https://news.ycombinator.com/submitted?id=" + hiringUser
+)
+
+// currentId returns the id of the latest thread. The resolved id is cached
+// for cacheTTL, and kept as a fallback if a later lookup fails.
+func (t *Thread) currentId(ctx context.Context, client *http.Client) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.resolvedId != 0 && time.Since(t.resolvedAt) < cacheTTL {
+		return t.resolvedId, nil
+	}
+
+	thread, err := findThread(ctx, client, hiringUser, t.TitlePrefix)
+	if err != nil {
+		if t.resolvedId != 0 && ctx.Err() == nil {
+			errorHandler.HandleErrorWithSection(err, fmt.Sprintf("Unable to refresh current thread, reusing %d", t.resolvedId), t.SourceName)
+			return t.resolvedId, nil
+		}
+		return 0, err
+	}
+
+	if thread.Id != t.resolvedId {
+		log.Printf("Using %s thread %d: %s\n", t.SourceName, thread.Id, thread.Title)
+	}
+
+	t.resolvedId = thread.Id
+	t.resolvedAt = time.Now()
+
+	return t.resolvedId, nil
+}
+
+// forget drops the cached thread id.
+func (t *Thread) forget() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resolvedId = 0
+	t.resolvedAt = time.Time{}
+}
+
+func (t *Thread) Scrape(ctx context.Context, opts ScrapeOptions) ([]*models.Notice, error) {
+	var notices []*models.Notice
+	var parent Parent
+
+	client := opts.client()
+	currentId, err := t.currentId(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find current thread: %w", err)
+	}
+
+	if err := getJSON(ctx, client, itemUrl(currentId), &parent); err != nil {
+		return nil, fmt.Errorf("unable to get current thread: %w", err)
+	}
+
+	// Sort by ID because I think newer posts are have higher IDs
+	kids := make([]int, 0, len(parent.Kids))
+	for _, id := range parent.Kids {
+		if !opts.Known[fmt.Sprint(id)] {
+			kids = append(kids, id)
+		}
+	}
+	sort.Slice(kids, func(i, j int) bool { return kids[i] > kids[j] })
+	if opts.Limit > 0 && opts.Limit < len(kids) {
+		kids = kids[:opts.Limit]
+	}
+
+	log.Printf("Fetching %d of %d comments from %s thread %d\n", len(kids), len(parent.Kids), t.SourceName, currentId)
+
+	stories, skipped := getStories(ctx, client, kids, opts.Workers)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, story := range stories {
+		s := t.StoryToNotice(story)
+		if FilterOutLessThan10Len(s) {
+			notices = append(notices, &s)
+		} else {
+			skipped = append(skipped, story.Id)
+		}
+	}
+	if opts.Skipped != nil {
+		for _, id := range skipped {
+			opts.Skipped[fmt.Sprint(id)] = true
+		}
+	}
+
+	fmt.Printf("Fetched %d items from %s\n", len(notices), t.SourceName)
+
+	return notices, nil
+}
+
+var (
+	tagRe   = regexp.MustCompile(`<[^>]*>`)
+	fieldRe = regexp.MustCompile(`^\s*([A-Za-zÀ-ÿ/ ]{2,30}?)\s*:\s*(.*)$`)
+)
+
+// paragraphs splits the HTML of a comment into plain text lines.
+func paragraphs(text string) []string {
+	text = strings.ReplaceAll(text, "<p>", "\n")
+	text = tagRe.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// templateFields reads the "Location: / Remote: / Technologies:" template
+// used by the seeking work threads. Keys are lower cased.
+func templateFields(text string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range paragraphs(text) {
+		match := fieldRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(match[1]))
+		if _, ok := fields[key]; !ok {
+			fields[key] = strings.TrimSpace(match[2])
+		}
+	}
+	return fields
+}
+
+// seekerSummary builds a short title out of the template fields, e.g.
+// "Go, Rust | Berlin, Germany | Remote".
+func seekerSummary(fields map[string]string) string {
+	var parts []string
+	if tech := fields["technologies"]; tech != "" {
+		parts = append(parts, tech)
+	}
+	if location := fields["location"]; location != "" {
+		parts = append(parts, location)
+	}
+	if remote := strings.ToLower(fields["remote"]); strings.HasPrefix(remote, "yes") || strings.HasPrefix(remote, "remote") {
+		parts = append(parts, "Remote")
+	}
+	return strings.Join(parts, " | ")
+}
+
+func extractSeekerTitle(text string) (string, string) {
+	summary := seekerSummary(templateFields(text))
+	if summary == "" {
+		return extractTitle(text)
+	}
+	return "Seeking work | " + summary, html.UnescapeString(text)
+}
+
+func extractFreelanceTitle(text string) (string, string) {
+	lines := paragraphs(text)
+	summary := seekerSummary(templateFields(text))
+
+	// Freelance comments are headed by "SEEKING WORK" or "SEEKING FREELANCER"
+	if len(lines) > 0 && strings.HasPrefix(strings.ToUpper(lines[0]), "SEEKING") {
+		title := lines[0]
+		if !strings.Contains(title, "|") && summary != "" {
+			title = title + " | " + summary
+		}
+		return title, html.UnescapeString(text)
+	}
+
+	if summary != "" {
+		return "Seeking work | " + summary, html.UnescapeString(text)
+	}
+
+	return extractTitle(text)
+}
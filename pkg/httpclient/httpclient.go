@@ -0,0 +1,269 @@
+// Package httpclient is the HTTP layer shared by every scraper. Requests that
+// are safe to repeat are retried with jittered exponential backoff when they
+// fail with a network error or a status that is likely to pass, such as 429
+// or 502. Every request waits for the token bucket of its host, and of its
+// source when the source has one, and for a free slot under the cap of
+// requests in flight.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"golang.org/x/time/rate"
+)
+
+// retryStatuses are the responses worth asking again for.
+var retryStatuses = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// idempotentMethods can be sent again without changing anything twice.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// Transport is an http.RoundTripper that retries failed idempotent requests.
+type Transport struct {
+	// Base makes the requests, http.DefaultTransport when nil
+	Base http.RoundTripper
+	// MaxAttempts caps the tries of a request, including the first
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubled for every retry
+	// after it
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between two attempts. A Retry-After asking for
+	// longer isn't honoured, the response is returned instead.
+	MaxDelay time.Duration
+	// HostLimit is the token bucket of every host
+	HostLimit config.RateLimit
+
+	// sleep waits for d, or until ctx is done. The tests replace it.
+	sleep func(ctx context.Context, d time.Duration) error
+	// inFlight holds a token for every request in progress, nil for no cap
+	inFlight chan struct{}
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// New returns a client whose requests are retried and limited as configured.
+func New(cfg config.HTTP) *http.Client {
+	t := &Transport{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+		HostLimit:   cfg.RateLimit,
+	}
+	if cfg.MaxInFlight > 0 {
+		t.inFlight = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return &http.Client{Transport: t}
+}
+
+type retriesKey struct{}
+
+// CountRetries returns a context that adds the retries of the requests made
+// with it to n.
+func CountRetries(ctx context.Context, n *atomic.Int64) context.Context {
+	return context.WithValue(ctx, retriesKey{}, n)
+}
+
+type sourceLimitKey struct{}
+
+type sourceLimit struct {
+	name  string
+	limit config.RateLimit
+}
+
+// WithRateLimit returns a context whose requests are also limited by the
+// token bucket of source, one bucket per host. The buckets are kept by the
+// transport, so they carry over between the runs of source.
+func WithRateLimit(ctx context.Context, source string, limit config.RateLimit) context.Context {
+	return context.WithValue(ctx, sourceLimitKey{}, sourceLimit{source, limit})
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if !idempotentMethods[req.Method] || (req.Body != nil && req.GetBody == nil) {
+		return t.send(base, req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.send(base, req)
+		if attempt >= t.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		var delay time.Duration
+		var reason string
+		switch {
+		case err != nil:
+			delay, reason = t.backoff(attempt), err.Error()
+		case retryStatuses[resp.StatusCode]:
+			delay, reason = t.backoff(attempt), resp.Status
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if after > t.MaxDelay {
+					return resp, nil
+				}
+				delay = max(delay, after)
+			}
+			drain(resp.Body)
+		default:
+			return resp, nil
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		log.Printf("Retrying %s %s in %v after %s (attempt %d of %d)\n", req.Method, req.URL.Redacted(), delay.Round(time.Millisecond), reason, attempt+1, t.MaxAttempts)
+		if n, ok := ctx.Value(retriesKey{}).(*atomic.Int64); ok {
+			n.Add(1)
+		}
+
+		if err := t.wait(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send makes a single attempt at req once the rate limits and the cap of
+// requests in flight allow it. The slot in flight is held until the body of
+// the response is closed.
+func (t *Transport) send(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+
+	if sl, ok := ctx.Value(sourceLimitKey{}).(sourceLimit); ok {
+		if err := t.limiter(sl.name+" "+host, sl.limit).Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.limiter(host, t.HostLimit).Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if t.inFlight == nil {
+		return base.RoundTrip(req)
+	}
+
+	select {
+	case t.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := sync.OnceFunc(func() { <-t.inFlight })
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// limiter returns the token bucket kept under key, creating it from limit.
+func (t *Transport) limiter(key string, limit config.RateLimit) *rate.Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if l, ok := t.limiters[key]; ok {
+		return l
+	}
+
+	l := rate.NewLimiter(rate.Inf, 0)
+	if limit.Rate > 0 {
+		l = rate.NewLimiter(rate.Limit(limit.Rate), max(limit.Burst, 1))
+	}
+	if t.limiters == nil {
+		t.limiters = map[string]*rate.Limiter{}
+	}
+	t.limiters[key] = l
+	return l
+}
+
+// releaseBody frees the slot of its request in flight once closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// backoff returns a random delay between half and all of BaseDelay doubled
+// for every attempt so far, capped at MaxDelay.
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.BaseDelay << (attempt - 1)
+	if delay > t.MaxDelay || delay <= 0 {
+		delay = t.MaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (t *Transport) wait(ctx context.Context, d time.Duration) error {
+	if t.sleep != nil {
+		return t.sleep(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or a date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// drain reads what's left of a response body so its connection can be
+// reused, giving up on large bodies.
+func drain(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, 64<<10)
+	body.Close()
+}
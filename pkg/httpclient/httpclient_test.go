@@ -0,0 +1,281 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+)
+
+// flakyServer answers with statuses in order, then 200 for every request
+// after them.
+type flakyServer struct {
+	statuses []int
+	header   http.Header
+	hits     atomic.Int32
+}
+
+func (f *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hit := int(f.hits.Add(1)) - 1
+	if hit < len(f.statuses) {
+		for k, v := range f.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(f.statuses[hit])
+		w.Write([]byte("try again"))
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+// newTestTransport returns a transport that records its delays instead of
+// sleeping.
+func newTestTransport(maxAttempts int) (*Transport, *[]time.Duration) {
+	var delays []time.Duration
+	return &Transport{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return ctx.Err()
+		},
+	}, &delays
+}
+
+func TestRetries(t *testing.T) {
+	testCases := []struct {
+		name      string
+		method    string
+		statuses  []int
+		header    http.Header
+		attempts  int
+		expected  int
+		hits      int32
+		minDelays []time.Duration
+		maxDelays []time.Duration
+	}{
+		{
+			name:      "Retry a 502 with backoff",
+			method:    http.MethodGet,
+			statuses:  []int{502, 503},
+			attempts:  4,
+			expected:  200,
+			hits:      3,
+			minDelays: []time.Duration{50 * time.Millisecond, 100 * time.Millisecond},
+			maxDelays: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name:     "Give up after the last attempt",
+			method:   http.MethodGet,
+			statuses: []int{502, 502, 502},
+			attempts: 3,
+			expected: 502,
+			hits:     3,
+		},
+		{
+			name:      "Wait as long as Retry-After asks",
+			method:    http.MethodGet,
+			statuses:  []int{429},
+			header:    http.Header{"Retry-After": {"3"}},
+			attempts:  4,
+			expected:  200,
+			hits:      2,
+			minDelays: []time.Duration{3 * time.Second},
+			maxDelays: []time.Duration{3 * time.Second},
+		},
+		{
+			name:     "Don't wait longer than the max delay",
+			method:   http.MethodGet,
+			statuses: []int{429},
+			header:   http.Header{"Retry-After": {"3600"}},
+			attempts: 4,
+			expected: 429,
+			hits:     1,
+		},
+		{
+			name:     "Don't retry a 404",
+			method:   http.MethodGet,
+			statuses: []int{404},
+			attempts: 4,
+			expected: 404,
+			hits:     1,
+		},
+		{
+			name:     "Don't retry a POST",
+			method:   http.MethodPost,
+			statuses: []int{503},
+			attempts: 4,
+			expected: 503,
+			hits:     1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &flakyServer{statuses: tc.statuses, header: tc.header}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			transport, delays := newTestTransport(tc.attempts)
+			var retries atomic.Int64
+			ctx := CountRetries(context.Background(), &retries)
+
+			req, _ := http.NewRequestWithContext(ctx, tc.method, ts.URL, nil)
+			resp, err := (&http.Client{Transport: transport}).Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expected {
+				t.Errorf("Expected status %d, got %d: %s", tc.expected, resp.StatusCode, body)
+			}
+			if hits := server.hits.Load(); hits != tc.hits {
+				t.Errorf("Expected %d requests, got %d", tc.hits, hits)
+			}
+			if retries.Load() != int64(tc.hits-1) {
+				t.Errorf("Expected %d retries to be counted, got %d", tc.hits-1, retries.Load())
+			}
+			for i, d := range *delays {
+				if i < len(tc.minDelays) && (d < tc.minDelays[i] || d > tc.maxDelays[i]) {
+					t.Errorf("Expected delay %d between %v and %v, got %v", i, tc.minDelays[i], tc.maxDelays[i], d)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	transport, delays := newTestTransport(3)
+	_, err := (&http.Client{Transport: transport}).Get(url)
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if len(*delays) != 2 {
+		t.Errorf("Expected 2 retries, got %d", len(*delays))
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	server := &flakyServer{statuses: []int{503, 503, 503}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// The first retry would wait an hour
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	transport := &Transport{MaxAttempts: 4, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if _, err := (&http.Client{Transport: transport}).Do(req); err == nil {
+		t.Errorf("Expected the wait for a retry to be cancelled")
+	}
+	if hits := server.hits.Load(); hits != 1 {
+		t.Errorf("Expected a single request, got %d", hits)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("120"); !ok || d != 2*time.Minute {
+		t.Errorf("Expected 2m, got %v", d)
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(date); !ok || d < 59*time.Minute || d > time.Hour {
+		t.Errorf("Expected about an hour, got %v", d)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Errorf("Expected an invalid Retry-After to be ignored")
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	testCases := []struct {
+		name      string
+		transport *Transport
+		ctx       context.Context
+	}{
+		{
+			name:      "Limit of the host",
+			transport: &Transport{HostLimit: config.RateLimit{Rate: 20, Burst: 1}},
+			ctx:       context.Background(),
+		},
+		{
+			name:      "Limit of the source",
+			transport: &Transport{},
+			ctx:       WithRateLimit(context.Background(), "slow", config.RateLimit{Rate: 20, Burst: 1}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &http.Client{Transport: tc.transport}
+			start := time.Now()
+			for i := 0; i < 5; i++ {
+				req, _ := http.NewRequestWithContext(tc.ctx, http.MethodGet, ts.URL, nil)
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				resp.Body.Close()
+			}
+
+			// The first request takes the burst, the other 4 wait 50ms each
+			if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+				t.Errorf("Expected 5 requests to take at least 200ms, took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	var current, peak atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	client := New(config.HTTP{MaxAttempts: 1, MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(ts.URL)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("Expected at most 2 requests at once, got %d", p)
+	}
+}
@@ -0,0 +1,87 @@
+// Package keywords tags notices with the technologies they mention, from a
+// dictionary of names and aliases such as Kubernetes and k8s.
+package keywords
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+var tagRe = regexp.MustCompile(`<[^>]*>`)
+
+const (
+	// wordChar is a character of a word, Go's regexp has no \b that works
+	// around C++ or .NET so the characters on either side are matched
+	wordChar    = `[\p{L}\p{N}_]`
+	nonWordChar = `[^\p{L}\p{N}_]`
+)
+
+// PlainText drops the tags of an HTML body and unescapes its entities.
+func PlainText(body string) string {
+	return html.UnescapeString(tagRe.ReplaceAllString(body, " "))
+}
+
+// Dictionary finds keywords in the title and body of notices.
+type Dictionary struct {
+	entries []entry
+}
+
+type entry struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// New builds a dictionary out of keywords. Every name and alias is matched
+// as a whole word, so Java isn't found in JavaScript. A term that starts
+// with a dot may also end a word when it is written as is, so .NET is found
+// in ASP.NET but not in example.net.
+func New(keywords []config.Keyword) *Dictionary {
+	d := &Dictionary{}
+	for _, k := range keywords {
+		terms := []string{term(k.Name, k.CaseSensitive)}
+		for _, alias := range k.Aliases {
+			terms = append(terms, term(alias, false))
+		}
+
+		re := regexp.MustCompile(`(?:` + strings.Join(terms, "|") + `)(?:` + nonWordChar + `|$)`)
+		d.entries = append(d.entries, entry{name: k.Name, re: re})
+	}
+	return d
+}
+
+// term matches value at the start of a word.
+func term(value string, caseSensitive bool) string {
+	quoted := regexp.QuoteMeta(value)
+	pattern := quoted
+	if !caseSensitive {
+		pattern = "(?i:" + quoted + ")"
+	}
+	pattern = `(?:^|` + nonWordChar + `)` + pattern
+
+	if strings.HasPrefix(value, ".") {
+		pattern += `|` + wordChar + quoted
+	}
+	return pattern
+}
+
+// Match returns the names of the keywords notice mentions, in the order of
+// the dictionary.
+func (d *Dictionary) Match(notice *models.Notice) []string {
+	return d.MatchText(notice.Title + "\n" + PlainText(notice.Body))
+}
+
+// MatchText returns the names of the keywords text mentions, in the order of
+// the dictionary.
+func (d *Dictionary) MatchText(text string) []string {
+	var found []string
+	for _, e := range d.entries {
+		if e.re.MatchString(text) {
+			found = append(found, e.name)
+		}
+	}
+	return found
+}
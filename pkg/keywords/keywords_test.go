@@ -0,0 +1,93 @@
+package keywords
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+func TestMatch(t *testing.T) {
+	d := New(config.DefaultKeywords())
+
+	testCases := []struct {
+		name     string
+		title    string
+		body     string
+		expected []string
+	}{
+		{
+			name:     "Names and aliases",
+			title:    "Senior Golang Engineer",
+			body:     "<p>We run everything on <b>k8s</b> and Postgres.</p>",
+			expected: []string{"Go", "Kubernetes", "PostgreSQL"},
+		},
+		{
+			name:     "Case sensitive names",
+			title:    "Backend Engineer (Go, React)",
+			body:     "Ready to go? We react quickly to feedback.",
+			expected: []string{"Go", "React"},
+		},
+		{
+			name:     "Common words aren't keywords",
+			title:    "Office Manager",
+			body:     "Come and go as you please, we react to rust on our rails.",
+			expected: nil,
+		},
+		{
+			name:     "Whole words only",
+			title:    "JavaScript Developer",
+			body:     "Experience with Javanese culture is a plus, goroutines too.",
+			expected: []string{"JavaScript"},
+		},
+		{
+			name:     "Symbols in names",
+			title:    "C++ and C# developers",
+			body:     "Our API is built with .NET and Node.js, deployed on AWS.",
+			expected: []string{"C++", "C#", ".NET", "JavaScript", "Node.js", "AWS"},
+		},
+		{
+			name:     "Dotted names after a word",
+			title:    "ASP.NET Developer",
+			body:     "See example.net for details.",
+			expected: []string{".NET"},
+		},
+		{
+			name:     "Dotted names after another word",
+			title:    "Legacy Systems Developer",
+			body:     "Maintaining VB.NET services",
+			expected: []string{".NET"},
+		},
+		{
+			name:     "Dotted names in other words",
+			title:    "Network Engineer",
+			body:     "Reach us at jobs@example.net or visit acme.network",
+			expected: nil,
+		},
+		{
+			name:     "Entities and tags in the body",
+			title:    "Platform Engineer",
+			body:     `<ul><li>Terraform&amp;Docker</li><li><a href="https://example.com/python">apply</a></li></ul>`,
+			expected: []string{"Docker", "Terraform"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			found := d.Match(&models.Notice{Title: tc.title, Body: tc.body})
+			if !slices.Equal(found, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, found)
+			}
+		})
+	}
+}
+
+func TestConfiguredDictionary(t *testing.T) {
+	d := New([]config.Keyword{{Name: "Zig", Aliases: []string{"ziglang"}}})
+
+	found := d.Match(&models.Notice{Title: "Systems programmer", Body: "We write ZIGLANG all day, and some Go"})
+	if !slices.Equal(found, []string{"Zig"}) {
+		t.Errorf("Expected only the configured keywords, got %v", found)
+	}
+}
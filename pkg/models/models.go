@@ -18,6 +18,46 @@ type Notice struct {
 	Raw           string
 	Guid          string
 	PublishedDate *time.Time
+	Company       *string
+	Role          *string
+	Location      *string
+	Remote        *bool
+	Salary        *string
+	// The salary parsed out of the notice, the annual figures are the range
+	// paid over a year whatever the period
+	SalaryMin       *float64
+	SalaryMax       *float64
+	SalaryCurrency  *string
+	SalaryPeriod    *string
+	SalaryAnnualMin *float64
+	SalaryAnnualMax *float64
+	// Workplace is remote-global, remote-region, hybrid or onsite, Regions
+	// the regions and timezones a remote notice is limited to and Places the
+	// countries and cities it mentions, both comma separated
+	Workplace *string
+	Regions   *string
+	Places    *string
+	// DedupeKey is the normalised company and title and SimHash the
+	// fingerprint of the body, CanonicalID is the notice this one is a
+	// duplicate of, nil for the first notice of a job
+	DedupeKey   *string
+	SimHash     *int64
+	CanonicalID *string
+	// ContentHash is the hash of the title and body, a notice found again
+	// with another hash was edited
+	ContentHash *string
+}
+
+// NoticeRevision is a previous version of a notice, saved when the notice
+// was edited. CreatedAt is when it was replaced.
+type NoticeRevision struct {
+	ID          string
+	NoticeID    string
+	Title       string
+	Body        string
+	Raw         string
+	ContentHash *string
+	CreatedAt   time.Time
 }
 
 type Keyword struct {
@@ -28,9 +68,18 @@ type Keyword struct {
 }
 
 type Source struct {
-	ID          string
 	Name        string
 	Description *string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	Homepage    *string
+	IconURL     *string
+}
+
+// FeedState holds the validators of the last response of a feed.
+type FeedState struct {
+	URL          string
+	ETag         *string
+	LastModified *string
+	UpdatedAt    time.Time
 }
@@ -0,0 +1,150 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thecsw/mira"
+)
+
+var (
+	authURL    = "https://www.reddit.com/api/v1/access_token"
+	apiBaseURL = "https://oauth.reddit.com"
+)
+
+const userAgent = "SALPHBot"
+
+// Client is a RedditClient for the Reddit OAuth API. Unlike mira it makes
+// every request with the given http.Client, and reports failed responses
+// instead of returning no posts.
+type Client struct {
+	http  *http.Client
+	creds mira.Credentials
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewClient(httpClient *http.Client, creds mira.Credentials) *Client {
+	return &Client{http: httpClient, creds: creds}
+}
+
+func credentialsFromEnv() mira.Credentials {
+	return mira.Credentials{
+		ClientId:     os.Getenv("REDDIT_ID"),
+		ClientSecret: os.Getenv("REDDIT_SECRET"),
+		Username:     os.Getenv("REDDIT_USERNAME"),
+		Password:     os.Getenv("REDDIT_PASSWORD"),
+		UserAgent:    userAgent,
+	}
+}
+
+// accessToken logs in with the script app credentials, reusing the token
+// until shortly before it expires.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "password")
+	form.Set("username", c.creds.Username)
+	form.Set("password", c.creds.Password)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(c.creds.ClientId, c.creds.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", c.creds.UserAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to log in to reddit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var auth struct {
+		AccessToken string  `json:"access_token"`
+		ExpiresIn   float64 `json:"expires_in"`
+		Error       string  `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("unable to decode reddit token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || auth.AccessToken == "" {
+		return "", fmt.Errorf("unable to log in to reddit: %s %s", resp.Status, auth.Error)
+	}
+
+	c.token = auth.AccessToken
+	c.expires = time.Now().Add(time.Duration(auth.ExpiresIn)*time.Second - time.Minute)
+
+	return c.token, nil
+}
+
+// forgetToken drops token unless another request already replaced it.
+func (c *Client) forgetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+func (c *Client) GetSubredditPosts(ctx context.Context, sr string, sort string, duration string, limit int) ([]mira.PostListingChild, error) {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("t", duration)
+	target := fmt.Sprintf("%s/r/%s/%s.json?%s", apiBaseURL, url.PathEscape(sr), sort, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", c.creds.UserAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked or expired early, log in again next time
+		c.forgetToken(token)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from r/%s", resp.Status, sr)
+	}
+
+	// mira's types don't match every field Reddit sends, e.g. preview
+	// resolutions are a list. Those fields are skipped, the rest still decode.
+	var listing mira.PostListing
+	var typeErr *json.UnmarshalTypeError
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil && !errors.As(err, &typeErr) {
+		return nil, fmt.Errorf("unable to decode posts of r/%s: %w", sr, err)
+	}
+
+	return listing.GetChildren(), nil
+}
@@ -1,104 +1,101 @@
 package reddit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"html"
-	"os"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
 	"github.com/thecsw/mira"
 )
 
 type Handler struct {
 	r          RedditClient
 	subreddits []string
+	limit      int
 }
 
+const defaultLimit = 20
+
 type RedditClient interface {
-	GetSubredditPosts(sr string, sort string, duration string, limit int) ([]mira.PostListingChild, error)
+	GetSubredditPosts(ctx context.Context, sr string, sort string, duration string, limit int) ([]mira.PostListingChild, error)
 }
 
 func InitRedditHandler(r RedditClient, subreddits []string) (*Handler, error) {
 	return &Handler{
 		r:          r,
 		subreddits: subreddits,
+		limit:      defaultLimit,
 	}, nil
 
 }
 
-func GetRedditHandler() (*Handler, error) {
-	var subreddits = []string{"forhire", "remotejs"}
-	r, err := mira.Init(mira.Credentials{
-		ClientId:     os.Getenv("REDDIT_ID"),
-		ClientSecret: os.Getenv("REDDIT_SECRET"),
-		Username:     os.Getenv("REDDIT_USERNAME"),
-		Password:     os.Getenv("REDDIT_PASSWORD"),
-		UserAgent:    `SALPHBot`,
-	})
+// GetRedditHandler logs in with the REDDIT_* credentials on the first
+// request, which is made with httpClient.
+func GetRedditHandler(httpClient *http.Client, subreddits []string, limit int) (*Handler, error) {
+	handler, err := InitRedditHandler(NewClient(httpClient, credentialsFromEnv()), subreddits)
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to reddit client %w", err)
+		return nil, err
+	}
+	if limit > 0 {
+		handler.limit = limit
 	}
 
-	return InitRedditHandler(r, subreddits)
-
+	return handler, nil
 }
 
-func (h *Handler) GetRedditPosts() ([]mira.PostListingChild, error) {
+// GetRedditPosts fetches every subreddit at once. Every subreddit gets a
+// result, in the same order as the subreddits, and the posts of the ones that
+// failed are left out.
+func (h *Handler) GetRedditPosts(ctx context.Context, sourceName string) ([]mira.PostListingChild, []source.FeedResult) {
 	var wg sync.WaitGroup
-	postsCh := make(chan []mira.PostListingChild, len(h.subreddits))
-	errCh := make(chan error, len(h.subreddits))
+	posts := make([][]mira.PostListingChild, len(h.subreddits))
+	results := make([]source.FeedResult, len(h.subreddits))
 
-	handleSubreddit := func(sr string) {
+	handleSubreddit := func(i int, sr string) {
 		defer wg.Done()
-		posts, err := h.r.GetSubredditPosts(sr, "new", "week", 20)
+		startTime := time.Now()
+		result := source.FeedResult{Source: sourceName, URL: "https://www.reddit.com/r/" + sr}
+
+		srPosts, err := h.r.GetSubredditPosts(ctx, sr, "new", "week", h.limit)
 		if err != nil {
-			errCh <- fmt.Errorf("failed to fetch from #{subreddit}: #{err}")
-			return
+			result.Err = fmt.Errorf("failed to fetch from r/%s: %w", sr, err)
+		} else {
+			posts[i] = srPosts
+			result.Items = len(srPosts)
 		}
 
-		posts = FilterHiringPosts(posts)
-		postsCh <- posts
+		result.Duration = time.Since(startTime)
+		results[i] = result
 	}
 
 	wg.Add(len(h.subreddits))
 
-	for _, sr := range h.subreddits {
-		go handleSubreddit(sr)
+	for i, sr := range h.subreddits {
+		go handleSubreddit(i, sr)
 	}
 
 	wg.Wait()
 
-	close(postsCh)
-	close(errCh)
-
-	if len(errCh) > 0 {
-		return nil, <-errCh
-	}
-
 	var allPosts []mira.PostListingChild
-	for posts := range postsCh {
-		allPosts = append(allPosts, posts...)
+	for _, p := range posts {
+		allPosts = append(allPosts, p...)
 	}
 
-	return allPosts, nil
+	return allPosts, results
 }
 
-const redditSourceName = "Reddit"
-
-func GetNoticesFromPosts() ([]*models.Notice, error) {
-	handler, err := GetRedditHandler()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load handler for reddit: %w", err)
-	}
-
-	posts, err := handler.GetRedditPosts()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load posts from reddit: %w", err)
-	}
+// GetNoticesFromPosts returns the notices of every subreddit that could be
+// fetched, with the results of all of them.
+func (h *Handler) GetNoticesFromPosts(ctx context.Context, sourceName string) ([]*models.Notice, []source.FeedResult) {
+	posts, results := h.GetRedditPosts(ctx, sourceName)
 
 	notices := make([]*models.Notice, len(posts))
 
@@ -112,11 +109,11 @@ func GetNoticesFromPosts() ([]*models.Notice, error) {
 			ID:            uuid.New().String(),
 			Title:         post.Data.Title,
 			Body:          html.UnescapeString(post.Data.SelftextHtml),
-			URL:           fmt.Sprintf(`https://www.reddit.com/%s`, post.GetPermalink()),
+			URL:           "https://www.reddit.com/" + strings.TrimPrefix(post.GetPermalink(), "/"),
 			AuthorName:    post.GetAuthor(),
 			AuthorURL:     fmt.Sprintf(`https://www.reddit.com/user/%s`, post.GetAuthor()),
 			ImageURL:      nil,
-			SourceID:      redditSourceName,
+			SourceID:      sourceName,
 			Raw:           string(jsonData),
 			Guid:          post.Data.Id,
 			PublishedDate: &t,
@@ -128,6 +125,6 @@ func GetNoticesFromPosts() ([]*models.Notice, error) {
 
 		notices[i] = newNotice
 	}
-	fmt.Printf("Fetched %d items from %s \n", len(notices), redditSourceName)
-	return notices, nil
+	fmt.Printf("Fetched %d items from %s \n", len(notices), sourceName)
+	return notices, results
 }
@@ -1,62 +1,284 @@
 package reddit
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/justinemmanuelmercado/go-scraper/pkg/cassette"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
 	"github.com/thecsw/mira"
 )
 
 type mockRedditClient struct {
 	posts []mira.PostListingChild
-	err   error
+	// errs are the errors of the subreddits that fail
+	errs map[string]error
 }
 
-func (m *mockRedditClient) GetSubredditPosts(sr string, sort string, duration string, limit int) ([]mira.PostListingChild, error) {
-	return m.posts, m.err
+func (m *mockRedditClient) GetSubredditPosts(ctx context.Context, sr string, sort string, duration string, limit int) ([]mira.PostListingChild, error) {
+	if err, ok := m.errs[sr]; ok {
+		return nil, err
+	}
+	return m.posts, nil
 }
 
 func TestGetRedditPosts(t *testing.T) {
+	posts := []mira.PostListingChild{{Data: mira.PostListingChildData{Title: "[HIRING] post1"}}, {Data: mira.PostListingChildData{Title: "[Hiring] post2"}}}
+
 	testCases := []struct {
 		name     string
-		posts    []mira.PostListingChild
-		err      error
+		errs     map[string]error
 		expected int
+		failed   []string
+		status   source.Status
 	}{
 		{
 			name:     "Successfully retrieve posts from multiple subreddits",
-			posts:    []mira.PostListingChild{{Data: mira.PostListingChildData{Title: "post1"}}, {Data: mira.PostListingChildData{Title: "post2"}}},
-			err:      nil,
 			expected: 4,
+			status:   source.StatusOK,
+		},
+		{
+			name:     "Keep the posts of the subreddits that worked",
+			errs:     map[string]error{"subreddit2": errors.New("error retrieving posts")},
+			expected: 2,
+			failed:   []string{"https://www.reddit.com/r/subreddit2"},
+			status:   source.StatusPartial,
 		},
 		{
-			name:     "Error retrieving posts",
-			posts:    nil,
-			err:      New("error retrieving posts"),
+			name: "Error retrieving posts",
+			errs: map[string]error{
+				"subreddit1": errors.New("error retrieving posts"),
+				"subreddit2": errors.New("error retrieving posts"),
+			},
 			expected: 0,
+			failed:   []string{"https://www.reddit.com/r/subreddit1", "https://www.reddit.com/r/subreddit2"},
+			status:   source.StatusFailed,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			mockClient := &mockRedditClient{
-				posts: tc.posts,
-				err:   tc.err,
-			}
+			mockClient := &mockRedditClient{posts: posts, errs: tc.errs}
 
 			handler, _ := InitRedditHandler(mockClient, []string{"subreddit1", "subreddit2"})
-			posts, err := handler.GetRedditPosts()
+			got, feeds := handler.GetRedditPosts(context.Background(), "Reddit")
 
-			if err != nil && tc.err == nil {
-				t.Errorf("Unexpected error: %v", err)
+			if len(got) != tc.expected {
+				t.Errorf("Expected %d posts, got %d", tc.expected, len(got))
+			}
+			if len(feeds) != 2 {
+				t.Fatalf("Expected a result for every subreddit, got %d", len(feeds))
 			}
 
-			if err == nil && tc.err != nil {
-				t.Errorf("Expected error but got none")
+			var failed []string
+			for _, feed := range feeds {
+				if feed.Err != nil {
+					failed = append(failed, feed.URL)
+					if !strings.Contains(feed.Err.Error(), "r/subreddit") || strings.Contains(feed.Err.Error(), "#{") {
+						t.Errorf("Expected the subreddit in the error, got %v", feed.Err)
+					}
+				}
+			}
+			if strings.Join(failed, ",") != strings.Join(tc.failed, ",") {
+				t.Errorf("Expected %v to fail, got %v", tc.failed, failed)
 			}
 
-			if len(posts) != tc.expected {
-				t.Errorf("Expected %d posts, got %d", tc.expected, len(posts))
+			result := source.Result{Source: "Reddit", Feeds: feeds}
+			if err := source.ReportFeeds(context.Background(), feeds); err != nil {
+				result.Err = err
+			}
+			if status := result.Status(); status != tc.status {
+				t.Errorf("Expected status %s, got %s", tc.status, status)
 			}
 		})
 	}
 }
+
+func TestFetchCassette(t *testing.T) {
+	recorder := cassette.Load(t, "forhire")
+	recorder.Filter = func(i *cassette.Interaction) {
+		if strings.HasSuffix(i.URL, "/access_token") {
+			i.Body = `{"access_token":"redacted","token_type":"bearer","expires_in":86400,"scope":"*"}`
+		}
+	}
+
+	s, err := newSource(config.Source{Name: "Reddit", Type: "reddit", Subreddits: []string{"forhire"}}, source.Deps{HTTPClient: recorder.Client()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	notices, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		guid   string
+		title  string
+		url    string
+		author string
+		image  string
+	}{
+		{
+			guid:   "1igm4p2",
+			title:  "[Hiring] Go developer for payment APIs - $80/hr, remote",
+			url:    "https://www.reddit.com/r/forhire/comments/1igm4p2/hiring_go_developer_for_payment_apis_80hr_remote/",
+			author: "acme_hiring",
+		},
+		{
+			guid:   "1igl9z0",
+			title:  "[For Hire] Frontend developer, React and Vue",
+			url:    "https://www.reddit.com/r/forhire/comments/1igl9z0/for_hire_frontend_developer_react_and_vue/",
+			author: "pixelpusher",
+		},
+		{
+			// Has a preview, which mira's types only partly match
+			guid:   "1igk2x9",
+			title:  "[Hiring] Illustrator for a children's book",
+			url:    "https://www.reddit.com/r/forhire/comments/1igk2x9/hiring_illustrator_for_a_childrens_book/",
+			author: "storytime_press",
+			image:  "https://preview.redd.it/k2x9sample.png?auto=webp&amp;s=5d1c",
+		},
+	}
+
+	if len(notices) != len(expected) {
+		t.Fatalf("Expected %d notices, got %d", len(expected), len(notices))
+	}
+	for i, e := range expected {
+		notice := notices[i]
+		if notice.Guid != e.guid || notice.Title != e.title || notice.URL != e.url {
+			t.Errorf("Unexpected notice %d: %s %q %s", i, notice.Guid, notice.Title, notice.URL)
+		}
+		if notice.AuthorName != e.author || notice.AuthorURL != "https://www.reddit.com/user/"+e.author {
+			t.Errorf("Unexpected author %q %q", notice.AuthorName, notice.AuthorURL)
+		}
+		image := ""
+		if notice.ImageURL != nil {
+			image = *notice.ImageURL
+		}
+		if image != e.image {
+			t.Errorf("Expected image %q, got %q", e.image, image)
+		}
+	}
+
+	if !strings.Contains(notices[0].Body, "<p><strong>Company:</strong> Acme Payments</p>") {
+		t.Errorf("Expected the unescaped HTML body, got %q", notices[0].Body)
+	}
+	if notices[0].PublishedDate == nil || notices[0].PublishedDate.Unix() != 1738598400 {
+		t.Errorf("Unexpected published date %v", notices[0].PublishedDate)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	loginStatus := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/access_token" {
+			w.WriteHeader(loginStatus)
+			w.Write([]byte(`{"access_token":"token","expires_in":86400,"error":"invalid_grant"}`))
+			return
+		}
+		http.Error(w, `{"reason":"private"}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	oldAuth, oldBase := authURL, apiBaseURL
+	authURL, apiBaseURL = server.URL+"/api/v1/access_token", server.URL
+	defer func() { authURL, apiBaseURL = oldAuth, oldBase }()
+
+	client := NewClient(server.Client(), credentialsFromEnv())
+
+	_, err := client.GetSubredditPosts(context.Background(), "forhire", "new", "week", 20)
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Expected the failed login to be reported, got %v", err)
+	}
+
+	loginStatus = http.StatusOK
+	_, err = client.GetSubredditPosts(context.Background(), "forhire", "new", "week", 20)
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden from r/forhire") {
+		t.Errorf("Expected the failed listing to be reported, got %v", err)
+	}
+}
+
+func TestClientToken(t *testing.T) {
+	var logins atomic.Int32
+	expiresIn := 3600
+	revoked := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/access_token" {
+			id, secret, _ := r.BasicAuth()
+			if id != "id" || secret != "secret" || r.FormValue("grant_type") != "password" || r.FormValue("username") != "bot" {
+				t.Errorf("Unexpected login %s:%s %v", id, secret, r.Form)
+			}
+			n := logins.Add(1)
+			fmt.Fprintf(w, `{"access_token":"token%d","expires_in":%d}`, n, expiresIn)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth == "Bearer "+revoked {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"kind":"Listing","data":{"children":[]}}`))
+	}))
+	defer server.Close()
+
+	oldAuth, oldBase := authURL, apiBaseURL
+	authURL, apiBaseURL = server.URL+"/api/v1/access_token", server.URL
+	defer func() { authURL, apiBaseURL = oldAuth, oldBase }()
+
+	client := NewClient(server.Client(), mira.Credentials{ClientId: "id", ClientSecret: "secret", Username: "bot", UserAgent: userAgent})
+	fetch := func() error {
+		_, err := client.GetSubredditPosts(context.Background(), "forhire", "new", "week", 20)
+		return err
+	}
+
+	// Concurrent requests share a single login
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fetch(); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if logins.Load() != 1 {
+		t.Fatalf("Expected the token to be reused, got %d logins", logins.Load())
+	}
+
+	// An expired token is refreshed
+	client.expires = time.Now().Add(-time.Second)
+	if err := fetch(); err != nil || logins.Load() != 2 {
+		t.Fatalf("Expected the expired token to be refreshed, got %d logins: %v", logins.Load(), err)
+	}
+
+	// A token that expires within a minute isn't reused
+	expiresIn = 30
+	client.expires = time.Now().Add(-time.Second)
+	fetch()
+	if err := fetch(); err != nil || logins.Load() != 4 {
+		t.Fatalf("Expected a short-lived token to be refreshed, got %d logins: %v", logins.Load(), err)
+	}
+
+	// A revoked token fails the request, the next one logs in again
+	expiresIn = 3600
+	client.expires = time.Now().Add(-time.Second)
+	fetch()
+	revoked = "token5"
+	if err := fetch(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected the revoked token to fail, got %v", err)
+	}
+	if err := fetch(); err != nil || logins.Load() != 6 {
+		t.Errorf("Expected to log in again after a 401, got %d logins: %v", logins.Load(), err)
+	}
+}
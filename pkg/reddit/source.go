@@ -0,0 +1,44 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+)
+
+type redditSource struct {
+	name    string
+	handler *Handler
+}
+
+func init() {
+	source.Register("reddit", newSource)
+}
+
+func newSource(cfg config.Source, deps source.Deps) (source.Source, error) {
+	handler, err := GetRedditHandler(deps.Client(), cfg.Subreddits, cfg.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load handler for reddit: %w", err)
+	}
+
+	return &redditSource{name: cfg.Name, handler: handler}, nil
+}
+
+func (s *redditSource) Name() string {
+	return s.name
+}
+
+func (s *redditSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	notices, feeds := s.handler.GetNoticesFromPosts(ctx, s.name)
+	if err := source.ReportFeeds(ctx, feeds); err != nil {
+		return nil, fmt.Errorf("failed to load posts from reddit: %w", err)
+	}
+	return notices, nil
+}
@@ -1,43 +1,130 @@
 package rss_feed
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/store"
 	"github.com/mmcdole/gofeed"
 )
 
+// ErrNotModified is returned by FetchItems when the feed hasn't changed since
+// it was last fetched.
+var ErrNotModified = errors.New("feed not modified")
+
 type RssFeed struct {
 	url        string
 	sourceName string
+	// limit caps the number of items taken from the feed, zero takes them all
+	limit int
+	// companyInTitle reads the company of items without one from titles
+	// like "Acme: Backend Engineer"
+	companyInTitle bool
+	client         *http.Client
+	// states holds the validators saved after the last fetch, nil to always
+	// download the feed
+	states store.FeedStateRepository
 }
 
-var RssFeedPairs = []RssFeed{
-	{"https://weworkremotely.com/categories/remote-full-stack-programming-jobs.rss", "WeWorkRemotely"},
-	{"https://weworkremotely.com/categories/remote-front-end-programming-jobs.rss", "WeWorkRemotely"},
-	{"https://weworkremotely.com/categories/remote-back-end-programming-jobs.rss", "WeWorkRemotely"},
-	{"https://remotive.com/remote-jobs/feed/software-dev", "Remotive"},
-	{"https://jobicy.com/?feed=job_feed&job_categories=dev&job_types=full-time", "JobIcy"},
-	{"https://www.fossjobs.net/rss/all/", "FOSSJobs"},
-	{"https://remoteok.io/remote-jobs.rss", "RemoteOK"},
-}
+// FetchItems downloads and parses the feed. The ETag and Last-Modified of
+// the previous response are sent along, so an unchanged feed answers 304
+// and isn't parsed again. The validators of the response are returned with
+// the items rather than saved, so a feed whose items fail to be stored is
+// downloaded in full the next time.
+func (rf *RssFeed) FetchItems(ctx context.Context) ([]*gofeed.Item, *models.FeedState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rf.url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", "Gofeed/1.0")
+
+	if state := rf.loadState(ctx); state != nil {
+		if state.ETag != nil {
+			req.Header.Set("If-None-Match", *state.ETag)
+		}
+		if state.LastModified != nil {
+			req.Header.Set("If-Modified-Since", *state.LastModified)
+		}
+	}
+
+	resp, err := rf.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
 
-func (rf *RssFeed) FetchItems() ([]*gofeed.Item, error) {
-	fp := gofeed.NewParser()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil, ErrNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
 
-	feed, err := fp.ParseURL(rf.url)
+	feed, err := gofeed.NewParser().Parse(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return feed.Items, nil
+	return feed.Items, rf.state(resp.Header), nil
 }
 
-func NoticesFromFeedItems(items []*gofeed.Item, sourceId string) []*models.Notice {
+// loadState returns the stored validators of the feed, nil when there are
+// none or they can't be read.
+func (rf *RssFeed) loadState(ctx context.Context) *models.FeedState {
+	if rf.states == nil {
+		return nil
+	}
+
+	state, err := rf.states.GetFeedState(ctx, rf.url)
+	errorHandler.HandleErrorWithSection(err, "Failed to get feed state, fetching "+rf.url+" in full", rf.sourceName)
+	return state
+}
+
+// state returns the validators of a response, nil when the feed doesn't
+// keep them.
+func (rf *RssFeed) state(header http.Header) *models.FeedState {
+	if rf.states == nil {
+		return nil
+	}
+
+	state := &models.FeedState{URL: rf.url}
+	if etag := header.Get("ETag"); etag != "" {
+		state.ETag = &etag
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		state.LastModified = &lastModified
+	}
+	return state
+}
+
+// companyOf returns the company of item, from the <company> element some
+// boards add or else, when companyInTitle is set, the part of the title
+// before a colon.
+func companyOf(item *gofeed.Item, companyInTitle bool) *string {
+	company := strings.TrimSpace(item.Custom["company"])
+	if company == "" && companyInTitle {
+		if before, _, ok := strings.Cut(item.Title, ":"); ok {
+			company = strings.TrimSpace(before)
+		}
+	}
+	if company == "" {
+		return nil
+	}
+	return &company
+}
+
+func NoticesFromFeedItems(items []*gofeed.Item, sourceId string, companyInTitle bool) []*models.Notice {
 	notices := make([]*models.Notice, len(items))
 
 	for i, item := range items {
@@ -55,6 +142,7 @@ func NoticesFromFeedItems(items []*gofeed.Item, sourceId string) []*models.Notic
 			Raw:           string(jsonData),
 			Guid:          item.GUID,
 			PublishedDate: item.PublishedParsed,
+			Company:       companyOf(item, companyInTitle),
 		}
 
 		if item.Image != nil {
@@ -72,44 +160,51 @@ func NoticesFromFeedItems(items []*gofeed.Item, sourceId string) []*models.Notic
 	return notices
 }
 
-func GetAllNotices() ([]*models.Notice, error) {
-
+// GetAllNotices fetches every feed at once. Every feed gets a result, in the
+// same order as feeds, and the feeds that failed are left out of the notices.
+func GetAllNotices(ctx context.Context, feeds []RssFeed) ([]*models.Notice, []source.FeedResult) {
 	var wg sync.WaitGroup
-	noticesCh := make(chan []*models.Notice, len(RssFeedPairs))
-	errCh := make(chan error, len(RssFeedPairs))
+	notices := make([][]*models.Notice, len(feeds))
+	results := make([]source.FeedResult, len(feeds))
 
-	handleFeed := func(feed RssFeed) {
+	handleFeed := func(i int, feed RssFeed) {
 		defer wg.Done()
-
-		items, err := feed.FetchItems()
-		if err != nil {
-			errCh <- fmt.Errorf("failed to fetch %s: %w", feed.sourceName, err)
-			return
+		startTime := time.Now()
+		result := source.FeedResult{Source: feed.sourceName, URL: feed.url}
+
+		items, state, err := feed.FetchItems(ctx)
+		switch {
+		case errors.Is(err, ErrNotModified):
+			log.Printf("Not modified since the last fetch: %s\n", feed.url)
+			result.NotModified = true
+		case err != nil:
+			result.Err = fmt.Errorf("failed to fetch %s: %w", feed.url, err)
+		default:
+			if feed.limit > 0 && len(items) > feed.limit {
+				items = items[:feed.limit]
+			}
+			fmt.Printf("Fetched %d items from %s\n", len(items), feed.url)
+			notices[i] = NoticesFromFeedItems(items, feed.sourceName, feed.companyInTitle)
+			result.Items = len(items)
+			result.State = state
 		}
-		fmt.Printf("Fetched %d items from %s\n", len(items), feed.sourceName)
-		noticesCh <- NoticesFromFeedItems(items, feed.sourceName)
+
+		result.Duration = time.Since(startTime)
+		results[i] = result
 	}
 
-	wg.Add(len(RssFeedPairs))
+	wg.Add(len(feeds))
 
-	for _, rssFeedPair := range RssFeedPairs {
-		go handleFeed(rssFeedPair)
+	for i, feed := range feeds {
+		go handleFeed(i, feed)
 	}
 
 	wg.Wait()
 
-	close(noticesCh)
-	close(errCh)
-
-	if len(errCh) > 0 {
-		// Don't stop the process if one feed fails
-		log.Printf("error fetching feeds: %v", <-errCh)
-	}
-
 	var allNotices []*models.Notice
-	for notices := range noticesCh {
-		allNotices = append(allNotices, notices...)
+	for _, n := range notices {
+		allNotices = append(allNotices, n...)
 	}
 
-	return allNotices, nil
+	return allNotices, results
 }
@@ -0,0 +1,268 @@
+package rss_feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/cassette"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/store"
+)
+
+func TestFetchCassettes(t *testing.T) {
+	type expectedNotice struct {
+		title      string
+		url        string
+		guid       string
+		authorName string
+		imageURL   string
+		company    string
+		published  time.Time
+	}
+
+	testCases := []struct {
+		cassette       string
+		url            string
+		limit          int
+		companyInTitle bool
+		expected       []expectedNotice
+	}{
+		{
+			cassette:       "weworkremotely",
+			url:            "https://weworkremotely.com/categories/remote-back-end-programming-jobs.rss",
+			companyInTitle: true,
+			expected: []expectedNotice{
+				{
+					title:     "Acme Payments: Senior Go Engineer",
+					url:       "https://weworkremotely.com/remote-jobs/acme-payments-senior-go-engineer",
+					guid:      "https://weworkremotely.com/remote-jobs/acme-payments-senior-go-engineer",
+					imageURL:  "https://wwr-pro.s3.amazonaws.com/logos/0099/8421/acme.png",
+					company:   "Acme Payments",
+					published: time.Date(2025, 2, 3, 14, 21, 7, 0, time.UTC),
+				},
+				{
+					title:     "Lumen Labs: Backend Developer (Python)",
+					url:       "https://weworkremotely.com/remote-jobs/lumen-labs-backend-developer-python",
+					guid:      "https://weworkremotely.com/remote-jobs/lumen-labs-backend-developer-python",
+					company:   "Lumen Labs",
+					published: time.Date(2025, 2, 2, 9, 5, 44, 0, time.UTC),
+				},
+				{
+					title:     "Quill: Staff Rust Engineer",
+					url:       "https://weworkremotely.com/remote-jobs/quill-staff-rust-engineer",
+					guid:      "https://weworkremotely.com/remote-jobs/quill-staff-rust-engineer",
+					company:   "Quill",
+					published: time.Date(2025, 2, 1, 18, 40, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			cassette: "remotive",
+			url:      "https://remotive.com/remote-jobs/feed/software-dev",
+			limit:    1,
+			expected: []expectedNotice{
+				{
+					title:      "Senior Frontend Engineer (React)",
+					url:        "https://remotive.com/remote-jobs/software-dev/senior-frontend-engineer-react-1984321",
+					guid:       "1984321",
+					authorName: "Widgets GmbH",
+					company:    "Widgets GmbH",
+					published:  time.Date(2025, 2, 3, 15, 12, 31, 0, time.UTC),
+				},
+			},
+		},
+		{
+			// Follows the redirect to remoteok.com
+			cassette: "remoteok",
+			url:      "https://remoteok.io/remote-jobs.rss",
+			expected: []expectedNotice{
+				{
+					title:     "Senior Backend Engineer",
+					url:       "https://remoteok.com/remote-jobs/remote-senior-backend-engineer-smith-jones-1084120",
+					guid:      "https://remoteok.com/remote-jobs/remote-senior-backend-engineer-smith-jones-1084120",
+					company:   "Smith & Jones",
+					published: time.Date(2025, 2, 3, 13, 0, 2, 0, time.UTC),
+				},
+				{
+					title:     "Site Reliability Engineer",
+					url:       "https://remoteok.com/remote-jobs/remote-site-reliability-engineer-lumen-labs-1084101",
+					guid:      "https://remoteok.com/remote-jobs/remote-site-reliability-engineer-lumen-labs-1084101",
+					company:   "Lumen Labs",
+					published: time.Date(2025, 2, 2, 21, 30, 45, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.cassette, func(t *testing.T) {
+			recorder := cassette.Load(t, tc.cassette)
+			cfg := config.Source{Name: tc.cassette, Type: "rss", URLs: []string{tc.url}, Limit: tc.limit, CompanyInTitle: tc.companyInTitle}
+
+			s, err := newSource(cfg, source.Deps{HTTPClient: recorder.Client()})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			notices, err := s.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(notices) != len(tc.expected) {
+				t.Fatalf("Expected %d notices, got %d", len(tc.expected), len(notices))
+			}
+			for i, expected := range tc.expected {
+				notice := notices[i]
+				if notice.Title != expected.title || notice.URL != expected.url || notice.Guid != expected.guid {
+					t.Errorf("Unexpected notice %d: %q %q %q", i, notice.Title, notice.URL, notice.Guid)
+				}
+				if notice.SourceID != tc.cassette || notice.Body == "" || notice.Raw == "" {
+					t.Errorf("Expected source, body and raw to be set on notice %d", i)
+				}
+				if notice.AuthorName != expected.authorName {
+					t.Errorf("Expected author %q, got %q", expected.authorName, notice.AuthorName)
+				}
+				imageURL := ""
+				if notice.ImageURL != nil {
+					imageURL = *notice.ImageURL
+				}
+				if imageURL != expected.imageURL {
+					t.Errorf("Expected image %q, got %q", expected.imageURL, imageURL)
+				}
+				company := ""
+				if notice.Company != nil {
+					company = *notice.Company
+				}
+				if company != expected.company {
+					t.Errorf("Expected company %q, got %q", expected.company, company)
+				}
+				if notice.PublishedDate == nil || !notice.PublishedDate.Equal(expected.published) {
+					t.Errorf("Expected published date %v, got %v", expected.published, notice.PublishedDate)
+				}
+			}
+		})
+	}
+}
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Jobs</title>
+<item><title>Go Developer</title><link>https://example.com/go</link><guid>go</guid><description>Go</description></item>
+</channel></rss>`
+
+func TestConditionalGet(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 03 Feb 2025 14:21:07 GMT")
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	backend := store.NewMemory()
+	cfg := config.Source{Name: "Jobs", Type: "rss", URLs: []string{server.URL + "/feed.rss"}}
+	s, err := newSource(cfg, source.Deps{FeedStates: backend.FeedStates})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	first := source.Run(context.Background(), []source.Source{s})[0]
+	if first.Err != nil || len(first.Notices) != 1 || first.NotModified() != 0 {
+		t.Fatalf("Expected the feed to be parsed, got %+v", first)
+	}
+
+	// Until they are saved the validators aren't sent
+	again := source.Run(context.Background(), []source.Source{s})[0]
+	if len(again.Notices) != 1 {
+		t.Fatalf("Expected the feed to be parsed again, got %+v", again)
+	}
+	state := again.Feeds[0].State
+	if state == nil || *state.ETag != `"v1"` {
+		t.Fatalf("Expected the validators with the result, got %+v", state)
+	}
+	if err := backend.FeedStates.SaveFeedState(context.Background(), state); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The saved validators are sent back and the feed is skipped
+	second := source.Run(context.Background(), []source.Source{s})[0]
+	if second.Err != nil || len(second.Notices) != 0 || second.NotModified() != 1 {
+		t.Errorf("Expected the feed to be reported as not modified, got %+v", second)
+	}
+
+	expected := []string{"|", "|", `"v1"|Mon, 03 Feb 2025 14:21:07 GMT`}
+	if strings.Join(conditional, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected conditional headers %q, got %q", expected, conditional)
+	}
+}
+
+func TestFailingFeeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok.rss" {
+			http.Error(w, "gone", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(testFeed))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		paths    []string
+		status   source.Status
+		notices  int
+		failures int
+	}{
+		{
+			name:     "Every failing feed is reported",
+			paths:    []string{"/ok.rss", "/gone.rss", "/missing.rss"},
+			status:   source.StatusPartial,
+			notices:  1,
+			failures: 2,
+		},
+		{
+			name:     "The source fails when every feed does",
+			paths:    []string{"/gone.rss", "/missing.rss"},
+			status:   source.StatusFailed,
+			failures: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Source{Name: "Jobs", Type: "rss"}
+			for _, path := range tc.paths {
+				cfg.URLs = append(cfg.URLs, server.URL+path)
+			}
+			s, err := newSource(cfg, source.Deps{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			result := source.Run(context.Background(), []source.Source{s})[0]
+			if result.Status() != tc.status {
+				t.Errorf("Expected status %s, got %s: %v", tc.status, result.Status(), result.Err)
+			}
+			if len(result.Notices) != tc.notices {
+				t.Errorf("Expected %d notices, got %d", tc.notices, len(result.Notices))
+			}
+			if len(result.Feeds) != len(tc.paths) || len(result.FailedFeeds()) != tc.failures {
+				t.Errorf("Expected %d of %d feeds to fail, got %+v", tc.failures, len(tc.paths), result.Feeds)
+			}
+			for _, feed := range result.FailedFeeds() {
+				if !strings.Contains(feed.Err.Error(), feed.URL) {
+					t.Errorf("Expected the error to name the feed, got %v", feed.Err)
+				}
+			}
+		})
+	}
+}
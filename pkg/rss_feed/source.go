@@ -0,0 +1,43 @@
+package rss_feed
+
+import (
+	"context"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/source"
+)
+
+type rssSource struct {
+	name  string
+	feeds []RssFeed
+}
+
+func init() {
+	source.Register("rss", newSource)
+}
+
+func newSource(cfg config.Source, deps source.Deps) (source.Source, error) {
+	feeds := make([]RssFeed, len(cfg.URLs))
+	for i, url := range cfg.URLs {
+		feeds[i] = RssFeed{url: url, sourceName: cfg.Name, limit: cfg.Limit, companyInTitle: cfg.CompanyInTitle, client: deps.Client(), states: deps.FeedStates}
+	}
+
+	return &rssSource{name: cfg.Name, feeds: feeds}, nil
+}
+
+func (s *rssSource) Name() string {
+	return s.name
+}
+
+func (s *rssSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	notices, feeds := GetAllNotices(ctx, s.feeds)
+	if err := source.ReportFeeds(ctx, feeds); err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
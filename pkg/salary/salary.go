@@ -0,0 +1,329 @@
+// Package salary finds pay ranges in job posts, like "$120k-$160k",
+// "€70.000 – 85.000 / year" or "$60/hr", and normalises them to a yearly
+// figure so they can be compared.
+package salary
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// Period is what a salary is paid for.
+type Period string
+
+const (
+	Hourly  Period = "hour"
+	Daily   Period = "day"
+	Weekly  Period = "week"
+	Monthly Period = "month"
+	Yearly  Period = "year"
+)
+
+// perYear is how many of each period make a year of full time work.
+var perYear = map[Period]float64{
+	Hourly:  2080,
+	Daily:   260,
+	Weekly:  52,
+	Monthly: 12,
+	Yearly:  1,
+}
+
+// Salary is a pay range, Min and Max are equal when a single figure is given.
+type Salary struct {
+	Min      float64
+	Max      float64
+	Currency string
+	Period   Period
+	// Text is the part of the post the salary was read from
+	Text string
+}
+
+// Annual returns the range paid over a year.
+func (s *Salary) Annual() (float64, float64) {
+	return s.Min * perYear[s.Period], s.Max * perYear[s.Period]
+}
+
+const (
+	// Codes are whole words, so EUR isn't read out of "European"
+	currencyPattern = `(?:(?:us|ca|c|au|a|nz|s)?\$|[€£₹]|\b(?:usd|cad|aud|nzd|sgd|eur|gbp|chf|inr|sek|nok|dkk|pln)\b)`
+	amountPattern   = `\d{1,3}(?:[.,  ]\d{3})+(?:[.,]\d{1,2})?|\d+(?:[.,]\d{1,2})?`
+)
+
+var (
+	salaryRe = regexp.MustCompile(`(?i)` + salaryPattern(`-|–|—|to`))
+	// betweenRe reads "between $130k and $150k". A range is only joined by
+	// "and" after "between", so "$85,000 and 401k" isn't one
+	betweenRe = regexp.MustCompile(`(?i)\bbetween\s+` + salaryPattern(`and`))
+	// contextRe finds the words that say a figure without a period or a range
+	// is pay, looked for just before it
+	contextRe   = regexp.MustCompile(`(?i)\b(?:salary|salaries|compensation|comp|pay|pays|paying|paid|base|wage|ote|earn|up to)\b`)
+	thousandsRe = regexp.MustCompile(`^(\d{1,3}(?:[.,  ]\d{3})+)(?:[.,](\d{1,2}))?$`)
+	tagRe       = regexp.MustCompile(`<[^>]*>`)
+)
+
+const (
+	// contextBefore is how many bytes before a figure are searched for
+	// contextRe
+	contextBefore = 40
+	// minAnnual and maxAnnual bound the yearly pay of a salary, figures
+	// outside of them are funding rounds, budgets or prices
+	minAnnual = 1000
+	maxAnnual = 10_000_000
+	// minHourly is the lowest hourly rate taken as pay, "$0.50/hr" is the
+	// price of something even though it adds up to over minAnnual
+	minHourly = 5
+)
+
+// salaryPattern matches a figure or a range of them joined by separators,
+// with their currency and period.
+func salaryPattern(separators string) string {
+	return `(?P<cur1>` + currencyPattern + `)?\s?` +
+		`(?P<min>` + amountPattern + `)(?P<k1>\s?k\b)?(?:\s?(?P<cur2>` + currencyPattern + `))?` +
+		`(?:\s*(?:` + separators + `)\s*(?P<cur3>` + currencyPattern + `)?\s?(?P<max>` + amountPattern + `)(?P<k2>\s?k\b)?)?` +
+		`(?:\s?(?P<cur4>` + currencyPattern + `))?` +
+		`(?:\s*(?:/\s*|(?:per|an|a)\s+)(?P<period>hour|hr|h|day|d|week|wk|month|mo|year|yr|y|annum)\b|\s+(?P<adverb>hourly|daily|weekly|monthly|yearly|annually|p\.?a\.?))?`
+}
+
+var currencies = map[string]string{
+	"$": "USD", "us$": "USD",
+	"ca$": "CAD", "c$": "CAD",
+	"au$": "AUD", "a$": "AUD",
+	"nz$": "NZD", "s$": "SGD",
+	"€": "EUR", "£": "GBP", "₹": "INR",
+}
+
+var periods = map[string]Period{
+	"hour": Hourly, "hr": Hourly, "h": Hourly, "hourly": Hourly,
+	"day": Daily, "d": Daily, "daily": Daily,
+	"week": Weekly, "wk": Weekly, "weekly": Weekly,
+	"month": Monthly, "mo": Monthly, "monthly": Monthly,
+	"year": Yearly, "yr": Yearly, "y": Yearly, "annum": Yearly, "yearly": Yearly, "annually": Yearly, "pa": Yearly, "p.a.": Yearly, "p.a": Yearly, "pa.": Yearly,
+}
+
+// Parse returns the first salary in text, which may be HTML. A figure is
+// only taken for a salary when it has a currency. Without a period it is
+// taken to be yearly, and it has to be a range, be given in thousands like
+// "$120k" or follow a word like "salary", so a budget or a funding round
+// isn't read as one.
+func Parse(text string) (*Salary, bool) {
+	text = html.UnescapeString(tagRe.ReplaceAllString(text, " "))
+
+	type found struct {
+		re    *regexp.Regexp
+		match []int
+	}
+	var matches []found
+	for _, re := range []*regexp.Regexp{betweenRe, salaryRe} {
+		for _, match := range re.FindAllStringSubmatchIndex(text, -1) {
+			matches = append(matches, found{re, match})
+		}
+	}
+	// A range after "between" starts before the figures in it, so it comes
+	// first
+	slices.SortStableFunc(matches, func(a, b found) int {
+		return a.match[0] - b.match[0]
+	})
+
+	for _, f := range matches {
+		group := func(name string) string {
+			i := f.re.SubexpIndex(name)
+			if f.match[2*i] < 0 {
+				return ""
+			}
+			return text[f.match[2*i]:f.match[2*i+1]]
+		}
+
+		context := text[max(0, f.match[0]-contextBefore):f.match[0]]
+		if s, ok := parseMatch(group, contextRe.MatchString(context)); ok {
+			s.Text = strings.TrimSpace(text[f.match[0]:f.match[1]])
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// parseMatch reads the salary of a match, context is set when the words
+// before it are about pay.
+func parseMatch(group func(name string) string, context bool) (*Salary, bool) {
+	var currency string
+	for _, name := range []string{"cur1", "cur2", "cur3", "cur4"} {
+		if currency = currencyCode(group(name)); currency != "" {
+			break
+		}
+	}
+	if currency == "" {
+		return nil, false
+	}
+
+	min, ok := parseAmount(group("min"), group("k1") != "")
+	if !ok {
+		return nil, false
+	}
+	max := min
+	if group("max") != "" {
+		if max, ok = parseAmount(group("max"), group("k2") != ""); !ok {
+			return nil, false
+		}
+		// "120-160k" puts the k on the upper figure only
+		if group("k2") != "" && group("k1") == "" && min < 1000 {
+			min *= 1000
+		}
+		if max < min {
+			return nil, false
+		}
+	}
+
+	period := periods[strings.ToLower(group("period")+group("adverb"))]
+	if period == "" {
+		// Without a period only a yearly looking figure is a salary
+		if group("max") == "" && group("k1") == "" && !context {
+			return nil, false
+		}
+		period = Yearly
+	}
+
+	s := &Salary{Min: min, Max: max, Currency: currency, Period: period}
+	if annualMin, annualMax := s.Annual(); annualMin < minAnnual || annualMax > maxAnnual {
+		return nil, false
+	}
+	if period == Hourly && min < minHourly {
+		return nil, false
+	}
+	return s, true
+}
+
+func currencyCode(symbol string) string {
+	if symbol == "" {
+		return ""
+	}
+	lower := strings.ToLower(symbol)
+	if code, ok := currencies[lower]; ok {
+		return code
+	}
+	return strings.ToUpper(symbol)
+}
+
+// parseAmount reads a figure with either , or . as the thousands or decimal
+// separator, e.g. 70.000, 120,000.00 or 62.50.
+func parseAmount(amount string, thousands bool) (float64, bool) {
+	if m := thousandsRe.FindStringSubmatch(amount); m != nil {
+		amount = strings.NewReplacer(".", "", ",", "", " ", "", " ", "", " ", "").Replace(m[1])
+		if m[2] != "" {
+			amount += "." + m[2]
+		}
+	} else {
+		amount = strings.Replace(amount, ",", ".", 1)
+	}
+
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	if thousands {
+		value *= 1000
+	}
+	return value, true
+}
+
+// Extract sets the salary of notice from the first one found in its salary,
+// title or body. It returns false when none of them has one.
+func Extract(notice *models.Notice) bool {
+	var candidates []string
+	if notice.Salary != nil {
+		candidates = append(candidates, *notice.Salary)
+	}
+	candidates = append(candidates, notice.Title, notice.Body)
+
+	for _, text := range candidates {
+		if s, ok := Parse(text); ok {
+			s.Apply(notice)
+			return true
+		}
+	}
+	return false
+}
+
+// Apply stores s on notice, keeping the salary text it already has.
+func (s *Salary) Apply(notice *models.Notice) {
+	annualMin, annualMax := s.Annual()
+	period := string(s.Period)
+
+	notice.SalaryMin = &s.Min
+	notice.SalaryMax = &s.Max
+	notice.SalaryCurrency = &s.Currency
+	notice.SalaryPeriod = &period
+	notice.SalaryAnnualMin = &annualMin
+	notice.SalaryAnnualMax = &annualMax
+	if notice.Salary == nil && s.Text != "" {
+		text := s.Text
+		notice.Salary = &text
+	}
+}
+
+// FromNotice returns the salary stored on notice.
+func FromNotice(notice *models.Notice) (*Salary, bool) {
+	if notice.SalaryMin == nil || notice.SalaryMax == nil || notice.SalaryCurrency == nil || notice.SalaryPeriod == nil {
+		return nil, false
+	}
+
+	s := &Salary{
+		Min:      *notice.SalaryMin,
+		Max:      *notice.SalaryMax,
+		Currency: *notice.SalaryCurrency,
+		Period:   Period(*notice.SalaryPeriod),
+	}
+	if notice.Salary != nil {
+		s.Text = *notice.Salary
+	}
+	return s, true
+}
+
+// String formats the salary like "USD 120,000 – 160,000 a year", adding
+// the yearly figure when it is paid by another period.
+func (s *Salary) String() string {
+	amount := formatAmount(s.Min)
+	if s.Max != s.Min {
+		amount += " – " + formatAmount(s.Max)
+	}
+
+	article := "a"
+	if s.Period == Hourly {
+		article = "an"
+	}
+	formatted := fmt.Sprintf("%s %s %s %s", s.Currency, amount, article, s.Period)
+
+	if s.Period != Yearly {
+		annualMin, annualMax := s.Annual()
+		annual := formatAmount(annualMin)
+		if annualMax != annualMin {
+			annual += " – " + formatAmount(annualMax)
+		}
+		formatted += fmt.Sprintf(" (about %s a year)", annual)
+	}
+	return formatted
+}
+
+// formatAmount writes value rounded to the cent with a comma between
+// thousands, and cents only when it has any.
+func formatAmount(value float64) string {
+	digits, cents, _ := strings.Cut(strconv.FormatFloat(value, 'f', 2, 64), ".")
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+
+	if cents != "00" {
+		b.WriteString("." + cents)
+	}
+	return b.String()
+}
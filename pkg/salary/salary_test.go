@@ -0,0 +1,161 @@
+package salary
+
+import (
+	"testing"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		text     string
+		min      float64
+		max      float64
+		currency string
+		period   Period
+		// annual is the yearly minimum, checked when set
+		annual float64
+		none   bool
+	}{
+		// Ranges in thousands
+		{text: "$120k-$160k", min: 120000, max: 160000, currency: "USD", period: Yearly},
+		{text: "$120K - $160K", min: 120000, max: 160000, currency: "USD", period: Yearly},
+		{text: "$150k–$190k + equity", min: 150000, max: 190000, currency: "USD", period: Yearly},
+		{text: "$120-160k", min: 120000, max: 160000, currency: "USD", period: Yearly},
+		{text: "120k-160k USD", min: 120000, max: 160000, currency: "USD", period: Yearly},
+		{text: "80-100k EUR", min: 80000, max: 100000, currency: "EUR", period: Yearly},
+		{text: "$100k to $120k", min: 100000, max: 120000, currency: "USD", period: Yearly},
+		{text: "£60k — £75k", min: 60000, max: 75000, currency: "GBP", period: Yearly},
+		{text: "€55k-€70k", min: 55000, max: 70000, currency: "EUR", period: Yearly},
+		{text: "CA$110k-130k", min: 110000, max: 130000, currency: "CAD", period: Yearly},
+		{text: "A$150k", min: 150000, max: 150000, currency: "AUD", period: Yearly},
+		{text: "CHF 120k", min: 120000, max: 120000, currency: "CHF", period: Yearly},
+		{text: "$180k", min: 180000, max: 180000, currency: "USD", period: Yearly},
+		{text: "Up to $150k", min: 150000, max: 150000, currency: "USD", period: Yearly},
+		{text: "$90K/yr", min: 90000, max: 90000, currency: "USD", period: Yearly},
+		{text: "$1.5k/month", min: 1500, max: 1500, currency: "USD", period: Monthly, annual: 18000},
+
+		// Full figures with separators
+		{text: "€70.000 – 85.000 / year", min: 70000, max: 85000, currency: "EUR", period: Yearly},
+		{text: "70.000€ - 85.000€", min: 70000, max: 85000, currency: "EUR", period: Yearly},
+		{text: "USD 100,000 - 120,000", min: 100000, max: 120000, currency: "USD", period: Yearly},
+		{text: "$150,000.00 per year", min: 150000, max: 150000, currency: "USD", period: Yearly},
+		{text: "£50,000 per annum", min: 50000, max: 50000, currency: "GBP", period: Yearly},
+		{text: "£45,000 - £55,000 p.a.", min: 45000, max: 55000, currency: "GBP", period: Yearly},
+		{text: "€60 000 – 70 000", min: 60000, max: 70000, currency: "EUR", period: Yearly},
+		{text: "Salary: 95000 EUR annually", min: 95000, max: 95000, currency: "EUR", period: Yearly},
+		{text: "₹25,00,000", none: true},
+		{text: "₹2,500,000 a year", min: 2500000, max: 2500000, currency: "INR", period: Yearly},
+		{text: "SEK 45,000/month", min: 45000, max: 45000, currency: "SEK", period: Monthly, annual: 540000},
+		{text: "$5,000/mo", min: 5000, max: 5000, currency: "USD", period: Monthly, annual: 60000},
+
+		// Hourly, daily and weekly rates
+		{text: "$60/hr", min: 60, max: 60, currency: "USD", period: Hourly, annual: 124800},
+		{text: "$60 / hour", min: 60, max: 60, currency: "USD", period: Hourly, annual: 124800},
+		{text: "$62.50/hour", min: 62.5, max: 62.5, currency: "USD", period: Hourly, annual: 130000},
+		{text: "$50-70/hr", min: 50, max: 70, currency: "USD", period: Hourly, annual: 104000},
+		{text: "$50 - $70 per hour", min: 50, max: 70, currency: "USD", period: Hourly},
+		{text: "$40 an hour", min: 40, max: 40, currency: "USD", period: Hourly},
+		{text: "40 USD hourly", min: 40, max: 40, currency: "USD", period: Hourly},
+		{text: "€500/day", min: 500, max: 500, currency: "EUR", period: Daily, annual: 130000},
+		{text: "£400-£500 per day", min: 400, max: 500, currency: "GBP", period: Daily},
+		{text: "$2,000/week", min: 2000, max: 2000, currency: "USD", period: Weekly, annual: 104000},
+		{text: "€45,50 per hour", min: 45.5, max: 45.5, currency: "EUR", period: Hourly},
+
+		// In a sentence or HTML
+		{text: "We pay between $130k and $150k", min: 130000, max: 150000, currency: "USD", period: Yearly},
+		{text: "<p>Compensation: <b>$140k&ndash;$170k</b> + equity</p>", min: 140000, max: 170000, currency: "USD", period: Yearly},
+		{text: "Acme | Senior Engineer | Berlin | €70.000 – 85.000", min: 70000, max: 85000, currency: "EUR", period: Yearly},
+		{text: "Raised $5 in seed, pays $120k-$140k", min: 120000, max: 140000, currency: "USD", period: Yearly},
+		{text: "Base salary of $85,000 and 401k matching", min: 85000, max: 85000, currency: "USD", period: Yearly},
+		{text: "Paying between 90,000 and 110,000 EUR", min: 90000, max: 110000, currency: "EUR", period: Yearly},
+		{text: "Compensation: $95,000 plus equity", min: 95000, max: 95000, currency: "USD", period: Yearly},
+
+		// Not salaries
+		{text: "We have 10k users and 2000 European customers", none: true},
+		{text: "Founded in 2015, 50 employees", none: true},
+		{text: "Costs $5 to apply", none: true},
+		{text: "Raised $20M from investors", none: true},
+		{text: "We raised $30,000,000 last year", none: true},
+		{text: "Our salary bands were set after we raised $30,000,000", none: true},
+		{text: "$1,200 equipment budget", none: true},
+		{text: "Storage costs $0.50/hr", none: true},
+		{text: "$4.99/hr per seat", none: true},
+		{text: "$85,000 and 401k", none: true},
+		{text: "$100,000 - $150,000,000", none: true},
+		{text: "Competitive salary", none: true},
+		{text: "$160k-$120k", none: true},
+		{text: "", none: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			s, ok := Parse(tc.text)
+			if tc.none {
+				if ok {
+					t.Errorf("Expected no salary, got %+v", s)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Expected a salary")
+			}
+
+			if s.Min != tc.min || s.Max != tc.max || s.Currency != tc.currency || s.Period != tc.period {
+				t.Errorf("Expected %v-%v %s a %s, got %v-%v %s a %s (%q)", tc.min, tc.max, tc.currency, tc.period, s.Min, s.Max, s.Currency, s.Period, s.Text)
+			}
+			if annual, _ := s.Annual(); tc.annual != 0 && annual != tc.annual {
+				t.Errorf("Expected %v a year, got %v", tc.annual, annual)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	testCases := []struct {
+		salary   Salary
+		expected string
+	}{
+		{Salary{Min: 120000, Max: 160000, Currency: "USD", Period: Yearly}, "USD 120,000 – 160,000 a year"},
+		{Salary{Min: 50000, Max: 50000, Currency: "GBP", Period: Yearly}, "GBP 50,000 a year"},
+		{Salary{Min: 62.5, Max: 62.5, Currency: "USD", Period: Hourly}, "USD 62.50 an hour (about 130,000 a year)"},
+		{Salary{Min: 400, Max: 500, Currency: "EUR", Period: Daily}, "EUR 400 – 500 a day (about 104,000 – 130,000 a year)"},
+		// The cents are rounded with the whole amount
+		{Salary{Min: 9.999, Max: 999.996, Currency: "USD", Period: Hourly}, "USD 10 – 1,000 an hour (about 20,797.92 – 2,079,991.68 a year)"},
+	}
+
+	for _, tc := range testCases {
+		if s := tc.salary.String(); s != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, s)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	header := "$150k-$190k"
+	notice := &models.Notice{Title: "Engineer", Body: "Pays $90/hr", Salary: &header}
+
+	if !Extract(notice) {
+		t.Fatalf("Expected a salary")
+	}
+	if *notice.SalaryMin != 150000 || *notice.SalaryAnnualMax != 190000 || *notice.Salary != header {
+		t.Errorf("Expected the salary of the header to be taken first, got %v-%v", *notice.SalaryMin, *notice.SalaryMax)
+	}
+
+	notice = &models.Notice{Title: "Contractor", Body: "<p>Rate: $90/hr</p>"}
+	if !Extract(notice) || *notice.SalaryPeriod != "hour" || *notice.SalaryAnnualMin != 187200 || *notice.Salary != "$90/hr" {
+		t.Errorf("Expected the hourly rate of the body, got %+v", notice)
+	}
+
+	s, ok := FromNotice(notice)
+	if !ok || s.String() != "USD 90 an hour (about 187,200 a year)" {
+		t.Errorf("Expected the stored salary back, got %v", s)
+	}
+
+	if Extract(&models.Notice{Title: "Engineer", Body: "Great team"}) {
+		t.Errorf("Expected no salary")
+	}
+	if _, ok := FromNotice(&models.Notice{}); ok {
+		t.Errorf("Expected no salary on an empty notice")
+	}
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
+)
+
+// Job is run every Interval. Run receives a context that is cancelled on
+// shutdown, it should stop fetching but finish writing what it already has.
+// It returns an error when the run didn't finish, e.g. it timed out.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	state  *State
+	jitter time.Duration
+	jobs   []Job
+	now    func() time.Time
+}
+
+// New creates a scheduler that delays every run by a random duration of up
+// to jitter, so jobs sharing an interval don't all fire at once.
+func New(state *State, jitter time.Duration) *Scheduler {
+	return &Scheduler{state: state, jitter: jitter, now: time.Now}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job until ctx is cancelled, then waits for the runs still
+// in progress. Each job is driven by its own loop, so a run that takes longer
+// than its interval delays the next one instead of overlapping it.
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(len(s.jobs))
+
+	for _, job := range s.jobs {
+		go func(job Job) {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+
+	wg.Wait()
+}
+
+// loop runs job until ctx is cancelled. Only the runs that finished are
+// saved as the last run, so a run cut short by a shutdown or a timeout is
+// made again after a restart. Until then the next run is still scheduled an
+// interval after it.
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	lastRun := s.state.LastRun(job.Name)
+	for {
+		wait := s.untilNext(job, lastRun)
+		log.Printf("Next run of %s in %v\n", job.Name, wait.Round(time.Second))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		lastRun = s.now()
+		err := job.Run(ctx)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Not saving the last run of %s, it didn't finish: %v\n", job.Name, err)
+			continue
+		}
+
+		err = s.state.SetLastRun(job.Name, lastRun)
+		errorHandler.HandleErrorWithSection(err, "Failed to save last run", job.Name)
+	}
+}
+
+// untilNext is the time left until the job is due after lastRun, plus
+// jitter.
+func (s *Scheduler) untilNext(job Job, lastRun time.Time) time.Duration {
+	var wait time.Duration
+	if !lastRun.IsZero() {
+		wait = lastRun.Add(job.Interval).Sub(s.now())
+	}
+	if wait < 0 {
+		wait = 0
+	}
+
+	if s.jitter > 0 {
+		wait += rand.N(s.jitter)
+	}
+
+	return wait
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartPreventsOverlap(t *testing.T) {
+	state, _ := LoadState("")
+	s := New(state, 0)
+
+	var running, maxRunning, runs, finished atomic.Int32
+	s.Add(Job{
+		Name:     "slow",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			n := running.Add(1)
+			if n > maxRunning.Load() {
+				maxRunning.Store(n)
+			}
+			runs.Add(1)
+			time.Sleep(30 * time.Millisecond)
+			running.Add(-1)
+			finished.Add(1)
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Start(ctx)
+
+	if runs.Load() < 2 {
+		t.Errorf("Expected job to run more than once, ran %d times", runs.Load())
+	}
+	if maxRunning.Load() != 1 {
+		t.Errorf("Expected runs not to overlap, saw %d at once", maxRunning.Load())
+	}
+	if finished.Load() != runs.Load() {
+		t.Errorf("Expected in-flight run to finish before Start returned")
+	}
+}
+
+func TestStartRespectsLastRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := state.SetLastRun("recent", time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Reload to make sure the last run survives a restart
+	state, err = LoadState(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var recentRuns, staleRuns atomic.Int32
+	s := New(state, 0)
+	s.Add(Job{Name: "recent", Interval: time.Hour, Run: func(ctx context.Context) error { recentRuns.Add(1); return nil }})
+	s.Add(Job{Name: "stale", Interval: time.Hour, Run: func(ctx context.Context) error { staleRuns.Add(1); return nil }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Start(ctx)
+
+	if recentRuns.Load() != 0 {
+		t.Errorf("Expected recently run job to wait for its interval")
+	}
+	if staleRuns.Load() != 1 {
+		t.Errorf("Expected job that never ran to run once, ran %d times", staleRuns.Load())
+	}
+
+	state, _ = LoadState(path)
+	if state.LastRun("stale").IsZero() {
+		t.Errorf("Expected last run of stale to be persisted")
+	}
+}
+
+func TestStartSkipsUnfinishedRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Times out, so it runs again after a restart but not before its
+	// interval
+	var timedOutRuns atomic.Int32
+	s := New(state, 0)
+	s.Add(Job{Name: "timed out", Interval: time.Hour, Run: func(ctx context.Context) error {
+		timedOutRuns.Add(1)
+		return context.DeadlineExceeded
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Start(ctx)
+
+	if timedOutRuns.Load() != 1 {
+		t.Errorf("Expected the timed out job to wait for its interval, ran %d times", timedOutRuns.Load())
+	}
+
+	// Shut down while the job is running, it still returns normally
+	s = New(state, 0)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	s.Add(Job{Name: "cancelled", Interval: time.Hour, Run: func(ctx context.Context) error {
+		cancel()
+		return nil
+	}})
+	s.Start(ctx)
+
+	state, _ = LoadState(path)
+	for _, name := range []string{"timed out", "cancelled"} {
+		if !state.LastRun(name).IsZero() {
+			t.Errorf("Expected the last run of %s not to be saved", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// State remembers when each job last ran, so a restart doesn't run every job
+// straight away. It is written to a JSON file after every run.
+type State struct {
+	mu       sync.Mutex
+	path     string
+	lastRuns map[string]time.Time
+}
+
+// LoadState reads the state at path. A missing file gives an empty state, an
+// empty path keeps the state in memory only.
+func LoadState(path string) (*State, error) {
+	state := &State{path: path, lastRuns: map[string]time.Time{}}
+	if path == "" {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state.lastRuns); err != nil {
+		return nil, fmt.Errorf("unable to decode state %s: %w", path, err)
+	}
+
+	return state, nil
+}
+
+func (s *State) LastRun(name string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRuns[name]
+}
+
+// SetLastRun records a run and persists the state.
+func (s *State) SetLastRun(name string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastRuns[name] = t
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.lastRuns, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves half a file
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
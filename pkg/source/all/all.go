@@ -0,0 +1,9 @@
+// Package all registers every scraper with the source registry. Import it for
+// its side effects; new job boards only need to be added here.
+package all
+
+import (
+	_ "github.com/justinemmanuelmercado/go-scraper/pkg/hackernews"
+	_ "github.com/justinemmanuelmercado/go-scraper/pkg/reddit"
+	_ "github.com/justinemmanuelmercado/go-scraper/pkg/rss_feed"
+)
@@ -0,0 +1,205 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// FeedResult is the outcome of a single feed of a source, e.g. one RSS url or
+// one subreddit.
+type FeedResult struct {
+	Source   string
+	URL      string
+	Items    int
+	Duration time.Duration
+	Err      error
+	// NotModified is set when the feed hadn't changed since it was last
+	// fetched, it has no items then
+	NotModified bool
+	// State holds the validators of the response, they are saved once the
+	// notices of the feed are stored. It is nil for feeds fetched without
+	// conditional requests
+	State *models.FeedState
+}
+
+type feedsKey struct{}
+
+// feedCollector gathers the feeds reported during a fetch.
+type feedCollector struct {
+	mu    sync.Mutex
+	feeds []FeedResult
+}
+
+func (c *feedCollector) results() []FeedResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]FeedResult(nil), c.feeds...)
+}
+
+// ReportFeeds adds feeds to the Result of the source fetched with ctx. It
+// returns an error joining theirs when every feed failed, which the source
+// should return from Fetch, and nil when at least one of them succeeded.
+func ReportFeeds(ctx context.Context, feeds []FeedResult) error {
+	if c, ok := ctx.Value(feedsKey{}).(*feedCollector); ok {
+		c.mu.Lock()
+		c.feeds = append(c.feeds, feeds...)
+		c.mu.Unlock()
+	}
+
+	var errs []error
+	for _, feed := range feeds {
+		if feed.Err != nil {
+			errs = append(errs, feed.Err)
+		}
+	}
+	if len(errs) == 0 || len(errs) < len(feeds) {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// Status sums up how the fetch of a source went.
+type Status int
+
+const (
+	StatusOK Status = iota
+	// StatusPartial is a source whose notices were fetched but some of its
+	// feeds failed
+	StatusPartial
+	StatusFailed
+	StatusTimedOut
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusPartial:
+		return "partially failed"
+	case StatusFailed:
+		return "failed"
+	case StatusTimedOut:
+		return "timed out"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Result is the outcome of fetching a single source.
+type Result struct {
+	Source   string
+	Notices  []*models.Notice
+	Err      error
+	Duration time.Duration
+	// TimedOut is set when the source didn't finish before its own timeout or
+	// the deadline of ctx
+	TimedOut bool
+	// Retries counts the requests of the source that had to be retried
+	Retries int
+	// Feeds are the feeds the source reported, empty for sources that only
+	// have one
+	Feeds []FeedResult
+}
+
+func (r Result) Status() Status {
+	switch {
+	case r.TimedOut:
+		return StatusTimedOut
+	case r.Err != nil:
+		return StatusFailed
+	}
+	for _, feed := range r.Feeds {
+		if feed.Err != nil {
+			return StatusPartial
+		}
+	}
+	return StatusOK
+}
+
+// FailedFeeds returns the feeds that failed.
+func (r Result) FailedFeeds() []FeedResult {
+	var failed []FeedResult
+	for _, feed := range r.Feeds {
+		if feed.Err != nil {
+			failed = append(failed, feed)
+		}
+	}
+	return failed
+}
+
+// NotModified counts the feeds that hadn't changed since they were last
+// fetched.
+func (r Result) NotModified() int {
+	count := 0
+	for _, feed := range r.Feeds {
+		if feed.NotModified {
+			count++
+		}
+	}
+	return count
+}
+
+// Report aggregates the results of a run.
+type Report struct {
+	Results []Result
+}
+
+// Status is StatusOK when every source succeeded and StatusFailed when none
+// of them did, StatusPartial otherwise.
+func (r Report) Status() Status {
+	failed := r.Count(StatusFailed) + r.Count(StatusTimedOut)
+	switch {
+	case len(r.Results) > 0 && failed == len(r.Results):
+		return StatusFailed
+	case r.Count(StatusOK) == len(r.Results):
+		return StatusOK
+	}
+	return StatusPartial
+}
+
+// Count returns the number of sources with status.
+func (r Report) Count(status Status) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status() == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (r Report) Retries() int {
+	count := 0
+	for _, result := range r.Results {
+		count += result.Retries
+	}
+	return count
+}
+
+func (r Report) NotModified() int {
+	count := 0
+	for _, result := range r.Results {
+		count += result.NotModified()
+	}
+	return count
+}
+
+// Summary is a line like "7 ok, 1 partially failed, 1 timed out", leaving
+// out the statuses no source has.
+func (r Report) Summary() string {
+	var parts []string
+	for _, status := range []Status{StatusOK, StatusPartial, StatusFailed, StatusTimedOut} {
+		if n := r.Count(status); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	if len(parts) == 0 {
+		return "no sources"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,227 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/httpclient"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/store"
+)
+
+// Source is a job board notices are scraped from.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]*models.Notice, error)
+}
+
+// Describer is implemented by sources that can create their own "Source" row
+// before their notices are inserted.
+type Describer interface {
+	Description() string
+	Homepage() string
+}
+
+// Configured is implemented by every source returned from Build.
+type Configured interface {
+	Config() config.Source
+}
+
+// Deps are the shared dependencies handed to every factory.
+type Deps struct {
+	// Known returns the guids already stored for a source
+	Known func(ctx context.Context, sourceName string) (map[string]bool, error)
+	// Recent returns the guids stored for a source since a time, sources that
+	// skip known guids fetch these again to find edits
+	Recent func(ctx context.Context, sourceName string, since time.Time) (map[string]bool, error)
+	// FeedStates keeps the validators of feeds for conditional requests, they
+	// aren't made when nil
+	FeedStates store.FeedStateRepository
+	// HTTPClient makes every request of the scrapers, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+// Client returns the HTTPClient, or http.DefaultClient when it isn't set.
+func (d Deps) Client() *http.Client {
+	if d.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return d.HTTPClient
+}
+
+// Factory builds a source from its configuration.
+type Factory func(cfg config.Source, deps Deps) (Source, error)
+
+var (
+	mu        sync.Mutex
+	factories = map[string]Factory{}
+)
+
+// Register makes kind usable as the type of a configured source, and valid
+// in the config. It is meant to be called from the init function of a scraper
+// package.
+func Register(kind string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := factories[kind]; ok {
+		panic(fmt.Sprintf("source: Register called twice for %s", kind))
+	}
+	factories[kind] = factory
+	config.RegisterType(kind)
+}
+
+// Build creates every enabled source in cfg, in the order they are declared.
+// All sources that can't be built are reported at once.
+func Build(cfg *config.Config, deps Deps) ([]Source, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var sources []Source
+	var errs []error
+	for _, sc := range cfg.Sources {
+		if !sc.IsEnabled() {
+			continue
+		}
+
+		factory, ok := factories[sc.Type]
+		if !ok {
+			errs = append(errs, fmt.Errorf("source %s: unknown type %q", sc.Name, sc.Type))
+			continue
+		}
+
+		s, err := factory(sc, deps)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", sc.Name, err))
+			continue
+		}
+
+		sources = append(sources, &configured{Source: s, cfg: sc})
+	}
+
+	return sources, errors.Join(errs...)
+}
+
+// configured applies the settings shared by every type of source.
+type configured struct {
+	Source
+	cfg config.Source
+}
+
+func (c *configured) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	notices, err := c.Source.Fetch(ctx)
+	if err != nil || c.cfg.Filters.IsEmpty() {
+		return notices, err
+	}
+
+	kept := notices[:0]
+	for _, notice := range notices {
+		if c.cfg.Filters.Match(notice) {
+			kept = append(kept, notice)
+		}
+	}
+
+	return kept, nil
+}
+
+func (c *configured) Config() config.Source {
+	return c.cfg
+}
+
+func (c *configured) Description() string {
+	if c.cfg.Description != "" {
+		return c.cfg.Description
+	}
+	if d, ok := c.Source.(Describer); ok {
+		return d.Description()
+	}
+	return ""
+}
+
+func (c *configured) Homepage() string {
+	if c.cfg.Homepage != "" {
+		return c.cfg.Homepage
+	}
+	if d, ok := c.Source.(Describer); ok {
+		return d.Homepage()
+	}
+	return ""
+}
+
+// Run fetches every source concurrently. Results are returned in the same
+// order as sources. A source that runs past its configured timeout, or the
+// deadline of ctx, is reported as timed out without waiting for it.
+func Run(ctx context.Context, sources []Source) []Result {
+	results := make([]Result, len(sources))
+
+	var wg sync.WaitGroup
+	wg.Add(len(sources))
+
+	for i, s := range sources {
+		go func(i int, s Source) {
+			defer wg.Done()
+			results[i] = fetch(ctx, s)
+		}(i, s)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func fetch(ctx context.Context, s Source) Result {
+	startTime := time.Now()
+
+	if c, ok := s.(Configured); ok {
+		cfg := c.Config()
+		if cfg.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+			defer cancel()
+		}
+		if cfg.RateLimit.Rate > 0 {
+			ctx = httpclient.WithRateLimit(ctx, s.Name(), cfg.RateLimit)
+		}
+	}
+
+	var retries atomic.Int64
+	ctx = httpclient.CountRetries(ctx, &retries)
+	feeds := &feedCollector{}
+	ctx = context.WithValue(ctx, feedsKey{}, feeds)
+
+	type fetched struct {
+		notices []*models.Notice
+		err     error
+	}
+	done := make(chan fetched, 1)
+	go func() {
+		notices, err := s.Fetch(ctx)
+		done <- fetched{notices, err}
+	}()
+
+	result := Result{Source: s.Name()}
+	select {
+	case f := <-done:
+		result.Notices, result.Err = f.notices, f.err
+	case <-ctx.Done():
+		// The fetch is left to notice the cancellation on its own
+		result.Err = ctx.Err()
+	}
+	result.Duration = time.Since(startTime)
+	result.Retries = int(retries.Load())
+	result.Feeds = feeds.results()
+
+	if result.Err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Notices = nil
+		result.TimedOut = true
+		result.Err = fmt.Errorf("timed out after %s: %w", result.Duration.Round(time.Millisecond), context.DeadlineExceeded)
+	}
+
+	return result
+}
@@ -0,0 +1,276 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/httpclient"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+type fakeSource struct {
+	name    string
+	notices []*models.Notice
+	err     error
+}
+
+func (f *fakeSource) Name() string {
+	return f.name
+}
+
+func (f *fakeSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	return f.notices, f.err
+}
+
+func TestRun(t *testing.T) {
+	sources := []Source{
+		&fakeSource{name: "first", notices: []*models.Notice{{Guid: "1"}, {Guid: "2"}}},
+		&fakeSource{name: "broken", err: errors.New("feed is down")},
+		&fakeSource{name: "last", notices: []*models.Notice{{Guid: "3"}}},
+	}
+
+	results := Run(context.Background(), sources)
+	if len(results) != len(sources) {
+		t.Fatalf("Expected %d results, got %d", len(sources), len(results))
+	}
+
+	expected := []struct {
+		source  string
+		notices int
+		err     bool
+	}{
+		{"first", 2, false},
+		{"broken", 0, true},
+		{"last", 1, false},
+	}
+
+	for i, e := range expected {
+		if results[i].Source != e.source {
+			t.Errorf("Expected result %d to be from %s, got %s", i, e.source, results[i].Source)
+		}
+		if len(results[i].Notices) != e.notices {
+			t.Errorf("Expected %d notices from %s, got %d", e.notices, e.source, len(results[i].Notices))
+		}
+		if (results[i].Err != nil) != e.err {
+			t.Errorf("Unexpected error from %s: %v", e.source, results[i].Err)
+		}
+	}
+}
+
+// stuckSource never returns, even when its context is done.
+type stuckSource struct {
+	name string
+}
+
+func (s *stuckSource) Name() string {
+	return s.name
+}
+
+func (s *stuckSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	select {}
+}
+
+// slowSource waits for its context, like a scraper stuck on a request.
+type slowSource struct {
+	name string
+}
+
+func (s *slowSource) Name() string {
+	return s.name
+}
+
+func (s *slowSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunTimeouts(t *testing.T) {
+	sources := []Source{
+		&configured{Source: &slowSource{name: "slow"}, cfg: config.Source{Timeout: 20 * time.Millisecond}},
+		&stuckSource{name: "stuck"},
+		&fakeSource{name: "fast", notices: []*models.Notice{{Guid: "1"}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	results := Run(ctx, sources)
+
+	// The slow source hits its own timeout, the stuck one the deadline of the
+	// whole run
+	for _, r := range results[:2] {
+		if !r.TimedOut || !errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Errorf("Expected %s to time out, got %v", r.Source, r.Err)
+		}
+	}
+	if results[0].Duration >= 100*time.Millisecond {
+		t.Errorf("Expected slow to stop after its own timeout, took %s", results[0].Duration)
+	}
+	if results[2].TimedOut || results[2].Err != nil || len(results[2].Notices) != 1 {
+		t.Errorf("Expected fast to finish, got %+v", results[2])
+	}
+}
+
+// requestSource gets url once.
+type requestSource struct {
+	client *http.Client
+	url    string
+}
+
+func (s *requestSource) Name() string {
+	return "request"
+}
+
+func (s *requestSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return nil, nil
+}
+
+func TestRunCountsRetries(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	client := httpclient.New(config.HTTP{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	results := Run(context.Background(), []Source{&requestSource{client: client, url: server.URL}})
+	if results[0].Err != nil || results[0].Retries != 2 {
+		t.Errorf("Expected 2 retries, got %d: %v", results[0].Retries, results[0].Err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	Register("zz-test", func(cfg config.Source, deps Deps) (Source, error) {
+		return &fakeSource{name: cfg.Name, notices: []*models.Notice{
+			{Title: "[Hiring] Go developer"},
+			{Title: "[For Hire] Go developer"},
+			{Title: "[Hiring] Unpaid internship"},
+		}}, nil
+	})
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(factories, "zz-test")
+		mu.Unlock()
+	})
+
+	disabled := false
+	cfg := &config.Config{Sources: []config.Source{
+		{Name: "filtered", Type: "zz-test", Description: "Test source", Filters: config.Filters{Include: []string{"hiring"}, Exclude: []string{"unpaid"}}},
+		{Name: "off", Type: "zz-test", Enabled: &disabled},
+	}}
+
+	sources, err := Build(cfg, Deps{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sources) != 1 || sources[0].Name() != "filtered" {
+		t.Fatalf("Expected only the enabled source to be built, got %d sources", len(sources))
+	}
+
+	if d, ok := sources[0].(Describer); !ok || d.Description() != "Test source" {
+		t.Errorf("Expected configured description")
+	}
+
+	notices, err := sources[0].Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(notices) != 1 || notices[0].Title != "[Hiring] Go developer" {
+		t.Errorf("Expected filters to keep a single notice, got %d", len(notices))
+	}
+
+	cfg.Sources = append(cfg.Sources, config.Source{Name: "mystery", Type: "carrier-pigeon"})
+	if _, err := Build(cfg, Deps{}); err == nil {
+		t.Errorf("Expected error for unknown type but got none")
+	}
+}
+
+func TestReport(t *testing.T) {
+	feedErr := errors.New("failed to fetch")
+	ok := Result{Source: "ok", Retries: 1, Feeds: []FeedResult{{Items: 2}, {NotModified: true}}}
+	partial := Result{Source: "partial", Retries: 2, Feeds: []FeedResult{{Items: 2}, {Err: feedErr}}}
+	failed := Result{Source: "failed", Err: feedErr, Feeds: []FeedResult{{Err: feedErr}}}
+	timedOut := Result{Source: "timed out", Err: context.DeadlineExceeded, TimedOut: true}
+
+	testCases := []struct {
+		name    string
+		results []Result
+		status  Status
+		summary string
+	}{
+		{"Every source worked", []Result{ok, ok}, StatusOK, "2 ok"},
+		{"A feed failed", []Result{ok, partial}, StatusPartial, "1 ok, 1 partially failed"},
+		{"A source failed", []Result{ok, failed}, StatusPartial, "1 ok, 1 failed"},
+		{"Every source failed", []Result{failed, timedOut}, StatusFailed, "1 failed, 1 timed out"},
+		{"Only partial failures", []Result{partial}, StatusPartial, "1 partially failed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := Report{Results: tc.results}
+			if status := report.Status(); status != tc.status {
+				t.Errorf("Expected status %s, got %s", tc.status, status)
+			}
+			if summary := report.Summary(); summary != tc.summary {
+				t.Errorf("Expected summary %q, got %q", tc.summary, summary)
+			}
+		})
+	}
+
+	report := Report{Results: []Result{ok, partial, failed}}
+	if report.Retries() != 3 || report.NotModified() != 1 {
+		t.Errorf("Expected 3 retries and 1 feed not modified, got %d and %d", report.Retries(), report.NotModified())
+	}
+	if failed := partial.FailedFeeds(); len(failed) != 1 || failed[0].Err != feedErr {
+		t.Errorf("Expected the failing feed, got %v", failed)
+	}
+}
+
+func TestReportFeeds(t *testing.T) {
+	feedErr := errors.New("failed to fetch")
+	s := &feedsSource{feeds: []FeedResult{{URL: "a", Items: 1}, {URL: "b", Err: feedErr}}}
+
+	results := Run(context.Background(), []Source{s})
+	if results[0].Err != nil || len(results[0].Feeds) != 2 || results[0].Status() != StatusPartial {
+		t.Errorf("Expected a partial result with both feeds, got %+v", results[0])
+	}
+
+	s.feeds = []FeedResult{{URL: "a", Err: feedErr}, {URL: "b", Err: feedErr}}
+	results = Run(context.Background(), []Source{s})
+	if !errors.Is(results[0].Err, feedErr) || results[0].Status() != StatusFailed {
+		t.Errorf("Expected every feed failing to fail the source, got %+v", results[0])
+	}
+}
+
+// feedsSource reports feeds from Fetch, as the scrapers do.
+type feedsSource struct {
+	feeds []FeedResult
+}
+
+func (s *feedsSource) Name() string {
+	return "feeds"
+}
+
+func (s *feedsSource) Fetch(ctx context.Context) ([]*models.Notice, error) {
+	if err := ReportFeeds(ctx, s.feeds); err != nil {
+		return nil, err
+	}
+	return []*models.Notice{{Title: "notice"}}, nil
+}
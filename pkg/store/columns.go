@@ -0,0 +1,172 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// column maps a database column to the field of model T it is read into. Only
+// writable columns are set on insert, the rest are filled in by the database.
+type column[T any] struct {
+	name     string
+	writable bool
+	field    func(m *T) any
+}
+
+var noticeColumns = []column[models.Notice]{
+	{"id", true, func(n *models.Notice) any { return &n.ID }},
+	{"title", true, func(n *models.Notice) any { return &n.Title }},
+	{"body", true, func(n *models.Notice) any { return &n.Body }},
+	{"url", true, func(n *models.Notice) any { return &n.URL }},
+	{"authorName", true, func(n *models.Notice) any { return &n.AuthorName }},
+	{"authorUrl", true, func(n *models.Notice) any { return &n.AuthorURL }},
+	{"imageUrl", true, func(n *models.Notice) any { return &n.ImageURL }},
+	{"createdAt", false, func(n *models.Notice) any { return &n.CreatedAt }},
+	{"updatedAt", false, func(n *models.Notice) any { return &n.UpdatedAt }},
+	{"sourceId", true, func(n *models.Notice) any { return &n.SourceID }},
+	{"raw", true, func(n *models.Notice) any { return &n.Raw }},
+	{"guid", true, func(n *models.Notice) any { return &n.Guid }},
+	{"publishedDate", true, func(n *models.Notice) any { return &n.PublishedDate }},
+	{"company", true, func(n *models.Notice) any { return &n.Company }},
+	{"role", true, func(n *models.Notice) any { return &n.Role }},
+	{"location", true, func(n *models.Notice) any { return &n.Location }},
+	{"remote", true, func(n *models.Notice) any { return &n.Remote }},
+	{"salary", true, func(n *models.Notice) any { return &n.Salary }},
+	{"salaryMin", true, func(n *models.Notice) any { return &n.SalaryMin }},
+	{"salaryMax", true, func(n *models.Notice) any { return &n.SalaryMax }},
+	{"salaryCurrency", true, func(n *models.Notice) any { return &n.SalaryCurrency }},
+	{"salaryPeriod", true, func(n *models.Notice) any { return &n.SalaryPeriod }},
+	{"salaryAnnualMin", true, func(n *models.Notice) any { return &n.SalaryAnnualMin }},
+	{"salaryAnnualMax", true, func(n *models.Notice) any { return &n.SalaryAnnualMax }},
+	{"workplace", true, func(n *models.Notice) any { return &n.Workplace }},
+	{"regions", true, func(n *models.Notice) any { return &n.Regions }},
+	{"places", true, func(n *models.Notice) any { return &n.Places }},
+	{"dedupeKey", true, func(n *models.Notice) any { return &n.DedupeKey }},
+	{"simhash", true, func(n *models.Notice) any { return &n.SimHash }},
+	{"canonicalId", true, func(n *models.Notice) any { return &n.CanonicalID }},
+	{"contentHash", true, func(n *models.Notice) any { return &n.ContentHash }},
+}
+
+// noticeKeyColumns identify a notice, or link it to others, so an update
+// leaves them alone.
+var noticeKeyColumns = []string{"id", "sourceId", "guid", "canonicalId"}
+
+var sourceColumns = []column[models.Source]{
+	{"name", true, func(s *models.Source) any { return &s.Name }},
+	{"description", true, func(s *models.Source) any { return &s.Description }},
+	{"createdAt", false, func(s *models.Source) any { return &s.CreatedAt }},
+	{"updatedAt", true, func(s *models.Source) any { return &s.UpdatedAt }},
+	{"homepage", true, func(s *models.Source) any { return &s.Homepage }},
+	{"iconUrl", true, func(s *models.Source) any { return &s.IconURL }},
+}
+
+var feedStateColumns = []column[models.FeedState]{
+	{"url", true, func(f *models.FeedState) any { return &f.URL }},
+	{"etag", true, func(f *models.FeedState) any { return &f.ETag }},
+	{"lastModified", true, func(f *models.FeedState) any { return &f.LastModified }},
+	{"updatedAt", false, func(f *models.FeedState) any { return &f.UpdatedAt }},
+}
+
+var keywordColumns = []column[models.Keyword]{
+	{"id", true, func(k *models.Keyword) any { return &k.ID }},
+	{"value", true, func(k *models.Keyword) any { return &k.Value }},
+	{"createdAt", false, func(k *models.Keyword) any { return &k.CreatedAt }},
+	{"updatedAt", false, func(k *models.Keyword) any { return &k.UpdatedAt }},
+}
+
+var revisionColumns = []column[models.NoticeRevision]{
+	{"id", true, func(r *models.NoticeRevision) any { return &r.ID }},
+	{"noticeId", true, func(r *models.NoticeRevision) any { return &r.NoticeID }},
+	{"title", true, func(r *models.NoticeRevision) any { return &r.Title }},
+	{"body", true, func(r *models.NoticeRevision) any { return &r.Body }},
+	{"raw", true, func(r *models.NoticeRevision) any { return &r.Raw }},
+	{"contentHash", true, func(r *models.NoticeRevision) any { return &r.ContentHash }},
+	{"createdAt", false, func(r *models.NoticeRevision) any { return &r.CreatedAt }},
+}
+
+// quote quotes an identifier, the Prisma column names are camel case.
+func quote(name string) string {
+	return `"` + name + `"`
+}
+
+// selectList returns the quoted column names, e.g. "id", "title".
+func selectList[T any](columns []column[T]) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = quote(c.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// insertColumns returns the quoted writable column names and their
+// placeholders, e.g. "id", "title" and $1, $2.
+func insertColumns[T any](columns []column[T]) (string, string) {
+	var names, placeholders []string
+	for _, c := range columns {
+		if !c.writable {
+			continue
+		}
+		names = append(names, quote(c.name))
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(placeholders)+1))
+	}
+	return strings.Join(names, ", "), strings.Join(placeholders, ", ")
+}
+
+// updateSet returns the writable columns not in skip set to placeholders
+// numbered from first, e.g. "title" = $2, "body" = $3.
+func updateSet[T any](columns []column[T], skip []string, first int) string {
+	var sets []string
+	for _, c := range columns {
+		if !c.writable || slices.Contains(skip, c.name) {
+			continue
+		}
+		sets = append(sets, fmt.Sprintf("%s = $%d", quote(c.name), first+len(sets)))
+	}
+	return strings.Join(sets, ", ")
+}
+
+// updateArgs returns the values of m for updateSet, in the same order.
+func updateArgs[T any](columns []column[T], skip []string, m *T) []any {
+	var args []any
+	for _, c := range columns {
+		if c.writable && !slices.Contains(skip, c.name) {
+			args = append(args, reflect.ValueOf(c.field(m)).Elem().Interface())
+		}
+	}
+	return args
+}
+
+// insertArgs returns the values of the writable columns of m, in the same
+// order as insertColumns.
+func insertArgs[T any](columns []column[T], m *T) []any {
+	var args []any
+	for _, c := range columns {
+		if c.writable {
+			args = append(args, reflect.ValueOf(c.field(m)).Elem().Interface())
+		}
+	}
+	return args
+}
+
+// rowScanner is a row of either pgx or database/sql.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRow reads a row selected with selectList(columns).
+func scanRow[T any](row rowScanner, columns []column[T]) (*T, error) {
+	var m T
+	dest := make([]any, len(columns))
+	for i, c := range columns {
+		dest[i] = c.field(&m)
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"context"
+	"io/fs"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+	"unsafe"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// mappedFields returns the names of the fields of T the columns point at.
+func mappedFields[T any](t *testing.T, columns []column[T]) []string {
+	t.Helper()
+
+	var m T
+	base := uintptr(unsafe.Pointer(&m))
+	typ := reflect.TypeOf(m)
+
+	var fields []string
+	for _, c := range columns {
+		offset := reflect.ValueOf(c.field(&m)).Pointer() - base
+		found := false
+		for i := 0; i < typ.NumField(); i++ {
+			if typ.Field(i).Offset == offset {
+				fields = append(fields, typ.Field(i).Name)
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Column %s doesn't point at a field of %s", c.name, typ.Name())
+		}
+	}
+	return fields
+}
+
+func checkModelCovered[T any](t *testing.T, columns []column[T]) {
+	t.Helper()
+
+	fields := mappedFields(t, columns)
+	typ := reflect.TypeOf(*new(T))
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		switch n := countOf(fields, name); {
+		case n == 0:
+			t.Errorf("%s.%s has no column", typ.Name(), name)
+		case n > 1:
+			t.Errorf("%s.%s is mapped by %d columns", typ.Name(), name, n)
+		}
+	}
+}
+
+func countOf(values []string, value string) int {
+	count := 0
+	for _, v := range values {
+		if v == value {
+			count++
+		}
+	}
+	return count
+}
+
+var (
+	createTableRe = regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS "(\w+)" \((.*?)\n\);`)
+	columnDefRe   = regexp.MustCompile(`(?m)^\s*"(\w+)" `)
+	addColumnRe   = regexp.MustCompile(`ALTER TABLE "(\w+)" ADD COLUMN (?:IF NOT EXISTS )?"(\w+)"`)
+	dropColumnRe  = regexp.MustCompile(`ALTER TABLE "(\w+)" DROP COLUMN (?:IF EXISTS )?"(\w+)"`)
+)
+
+// migratedColumns replays the up migrations in dir to find the columns of
+// every table, without needing a database.
+func migratedColumns(t *testing.T, files fs.FS, dir string) map[string][]string {
+	t.Helper()
+
+	migrations, err := loadMigrations(files, dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tables := map[string][]string{}
+	for _, m := range migrations {
+		for _, match := range createTableRe.FindAllStringSubmatch(m.Up, -1) {
+			for _, def := range columnDefRe.FindAllStringSubmatch(match[2], -1) {
+				tables[match[1]] = append(tables[match[1]], def[1])
+			}
+		}
+		for _, match := range addColumnRe.FindAllStringSubmatch(m.Up, -1) {
+			tables[match[1]] = append(tables[match[1]], match[2])
+		}
+		for _, match := range dropColumnRe.FindAllStringSubmatch(m.Up, -1) {
+			tables[match[1]] = slices.DeleteFunc(tables[match[1]], func(c string) bool { return c == match[2] })
+		}
+	}
+
+	return tables
+}
+
+func columnNames[T any](columns []column[T]) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestColumnsCoverModels(t *testing.T) {
+	checkModelCovered(t, noticeColumns)
+	checkModelCovered(t, sourceColumns)
+	checkModelCovered(t, feedStateColumns)
+	checkModelCovered(t, keywordColumns)
+	checkModelCovered(t, revisionColumns)
+}
+
+func TestColumnsMatchMigrations(t *testing.T) {
+	for dir, files := range map[string]fs.FS{
+		"migrations":        migrationFiles,
+		"migrations/sqlite": sqliteMigrationFiles,
+	} {
+		tables := migratedColumns(t, files, dir)
+
+		testCases := []struct {
+			table   string
+			columns []string
+		}{
+			{"Notice", columnNames(noticeColumns)},
+			{"Source", columnNames(sourceColumns)},
+			{"FeedState", columnNames(feedStateColumns)},
+			{"Keyword", columnNames(keywordColumns)},
+			{"NoticeRevision", columnNames(revisionColumns)},
+		}
+
+		for _, tc := range testCases {
+			migrated := append([]string(nil), tables[tc.table]...)
+			sort.Strings(migrated)
+			if !slices.Equal(migrated, tc.columns) {
+				t.Errorf("Columns of %s in %s are out of sync\nmigrations: %v\nstore:      %v", tc.table, dir, migrated, tc.columns)
+			}
+		}
+	}
+}
+
+func TestColumnsMatchDatabase(t *testing.T) {
+	ctx := context.Background()
+	pool := migratedPool(t)
+
+	for table, expected := range map[string][]string{
+		"Notice":         columnNames(noticeColumns),
+		"Source":         columnNames(sourceColumns),
+		"FeedState":      columnNames(feedStateColumns),
+		"Keyword":        columnNames(keywordColumns),
+		"NoticeRevision": columnNames(revisionColumns),
+	} {
+		rows, err := pool.Query(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY column_name`, table)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var actual []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			actual = append(actual, name)
+		}
+		rows.Close()
+		sort.Strings(actual)
+
+		if !slices.Equal(actual, expected) {
+			t.Errorf("Columns of %s are out of sync\ndatabase: %v\nstore:    %v", table, actual, expected)
+		}
+	}
+
+	// Round trip a notice through every column
+	sourceStore := InitSource(pool)
+	if err := sourceStore.EnsureSource(ctx, "Test", "Test source", "https://example.com"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	source, err := sourceStore.GetSourceByName(ctx, "Test")
+	if err != nil || source.Homepage == nil || *source.Homepage != "https://example.com" {
+		t.Errorf("Unexpected source %+v: %v", source, err)
+	}
+
+	company, remote, annual := "Acme", true, 124800.0
+	noticeStore := InitNotice(pool)
+	inserted, err := noticeStore.CreateNotices(ctx, []*models.Notice{
+		{ID: "1", Title: "Engineer", SourceID: "Test", Guid: "a", Company: &company, Remote: &remote, SalaryAnnualMin: &annual},
+	})
+	if err != nil || len(inserted) != 1 {
+		t.Fatalf("Expected 1 inserted notice, got %d: %v", len(inserted), err)
+	}
+
+	notices, err := noticeStore.GetLatestNotices(ctx)
+	if err != nil || len(notices) != 1 {
+		t.Fatalf("Expected 1 notice, got %d: %v", len(notices), err)
+	}
+	if notices[0].Company == nil || *notices[0].Company != company || notices[0].Remote == nil || !*notices[0].Remote || notices[0].SalaryAnnualMin == nil || *notices[0].SalaryAnnualMin != annual {
+		t.Errorf("Unexpected notice %+v", notices[0])
+	}
+}
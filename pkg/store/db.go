@@ -2,22 +2,112 @@ package store
 
 import (
 	"context"
-	"github.com/jackc/pgx/v5"
-	"log"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func OpenDB(connString string) (*pgx.Conn, error) {
-	conn, err := pgx.Connect(context.Background(), connString)
+// PoolConfig holds the pool settings that can be tuned without touching the
+// connection string. Zero values keep the pgxpool defaults.
+type PoolConfig struct {
+	URL             string
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// SSLMode overrides the sslmode of URL, e.g. require or verify-full
+	SSLMode string
+}
+
+// DatabaseURL returns DATABASE_URL, the same variable schema.prisma reads. If
+// it isn't set the URL is built from the POSTGRES_* variables.
+func DatabaseURL() string {
+	if dbUrl := os.Getenv("DATABASE_URL"); dbUrl != "" {
+		return dbUrl
+	}
+
+	host := os.Getenv("POSTGRES_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	port := os.Getenv("POSTGRES_PORT")
+	if port == "" {
+		port = "5432"
+	}
+
+	u := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD")),
+		Host:     host + ":" + port,
+		Path:     os.Getenv("POSTGRES_DB"),
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
 
+// parseURL adapts a Prisma style URL for pgx. Prisma's schema parameter isn't
+// understood by Postgres, it becomes the search_path instead.
+func parseURL(connString string, sslMode string) (string, string, error) {
+	u, err := url.Parse(connString)
 	if err != nil {
-		return nil, err
+		return "", "", fmt.Errorf("invalid database url: %w", err)
 	}
 
-	return conn, nil
+	query := u.Query()
+	schema := query.Get("schema")
+	query.Del("schema")
+	if sslMode != "" {
+		query.Set("sslmode", sslMode)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), schema, nil
 }
 
-func CloseDB(conn *pgx.Conn) {
-	if err := conn.Close(context.Background()); err != nil {
-		log.Printf("Failed to close connection %v\n", err)
+func OpenDB(ctx context.Context, cfg PoolConfig) (*pgxpool.Pool, error) {
+	connString, schema, err := parseURL(cfg.URL, cfg.SSLMode)
+	if err != nil {
+		return nil, err
 	}
+
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+
+	if schema != "" {
+		poolConfig.ConnConfig.RuntimeParams["search_path"] = schema
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The pool connects lazily, ping so a bad DSN fails at startup
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+func CloseDB(pool *pgxpool.Pool) {
+	pool.Close()
 }
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// RowError is a notice that couldn't be inserted.
+type RowError struct {
+	Notice *models.Notice
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("%s notice %q: %s", e.Notice.SourceID, e.Notice.Guid, e.Reason())
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Reason explains the common failures in plain words, falling back to the
+// database error.
+func (e *RowError) Reason() string {
+	var sqliteErr *sqlite.Error
+	if errors.As(e.Err, &sqliteErr) {
+		return e.sqliteReason(sqliteErr)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(e.Err, &pgErr) {
+		return e.Err.Error()
+	}
+
+	switch pgErr.Code {
+	case "23503":
+		return fmt.Sprintf("source %q doesn't exist", e.Notice.SourceID)
+	case "22001", "54000":
+		return "value is too long: " + pgErr.Message
+	case "22021":
+		return "text isn't valid UTF-8: " + pgErr.Message
+	case "23502":
+		return fmt.Sprintf("column %s is required", pgErr.ColumnName)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *RowError) sqliteReason(err *sqlite.Error) string {
+	switch err.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return fmt.Sprintf("source %q doesn't exist", e.Notice.SourceID)
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return err.Error()
+	}
+
+	return e.Err.Error()
+}
+
+// isRowError tells whether err was caused by the values of a row, a
+// constraint or data error that inserting the rows one at a time can pin on
+// a notice. Anything else, like a lost connection or a cancelled context,
+// fails every row the same way.
+func isRowError(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_CONSTRAINT, sqlite3.SQLITE_TOOBIG, sqlite3.SQLITE_MISMATCH:
+			return true
+		}
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	// Class 22 is data exceptions, class 23 integrity constraint violations
+	// and 54000 a row too large for an index
+	return strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23") || pgErr.Code == "54000"
+}
+
+// InsertError lists every notice CreateNotices couldn't insert, or
+// UpdateNotices couldn't update. The other notices were saved.
+type InsertError struct {
+	Rows      []*RowError
+	Attempted int
+	// Update is set for the errors of UpdateNotices
+	Update bool
+}
+
+func (e *InsertError) Error() string {
+	reasons := make([]string, len(e.Rows))
+	for i, row := range e.Rows {
+		reasons[i] = row.Error()
+	}
+	action := "insert"
+	if e.Update {
+		action = "update"
+	}
+	return fmt.Sprintf("failed to %s %d of %d notices: %s", action, len(e.Rows), e.Attempted, strings.Join(reasons, "; "))
+}
+
+func (e *InsertError) Unwrap() []error {
+	errs := make([]error, len(e.Rows))
+	for i, row := range e.Rows {
+		errs[i] = row
+	}
+	return errs
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+func TestInsertError(t *testing.T) {
+	missingSource := &pgconn.PgError{Code: "23503", Message: `insert or update on table "Notice" violates foreign key constraint`}
+	tooLong := &pgconn.PgError{Code: "54000", Message: "index row size 3000 exceeds btree version 4 maximum 2704"}
+
+	err := error(&InsertError{
+		Attempted: 5,
+		Rows: []*RowError{
+			{Notice: &models.Notice{SourceID: "Nowhere", Guid: "1"}, Err: missingSource},
+			{Notice: &models.Notice{SourceID: "RemoteOK", Guid: "2"}, Err: tooLong},
+		},
+	})
+
+	for _, expected := range []string{
+		"failed to insert 2 of 5 notices",
+		`Nowhere notice "1": source "Nowhere" doesn't exist`,
+		`RemoteOK notice "2": value is too long`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23503" {
+		t.Errorf("Expected the database errors to be unwrappable")
+	}
+
+	err = &InsertError{Attempted: 3, Update: true, Rows: []*RowError{{Notice: &models.Notice{SourceID: "RemoteOK", Guid: "2"}, Err: tooLong}}}
+	if !strings.HasPrefix(err.Error(), "failed to update 1 of 3 notices") {
+		t.Errorf("Expected a failed update, got %v", err)
+	}
+}
+
+func TestIsRowError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&pgconn.PgError{Code: "23505"}, true},
+		{&pgconn.PgError{Code: "22001"}, true},
+		{&pgconn.PgError{Code: "54000"}, true},
+		{fmt.Errorf("batch: %w", &pgconn.PgError{Code: "23503"}), true},
+		// Admin shutdown, insufficient resources
+		{&pgconn.PgError{Code: "57P01"}, false},
+		{&pgconn.PgError{Code: "53100"}, false},
+		{context.Canceled, false},
+		{errors.New("conn closed"), false},
+	}
+
+	for _, test := range tests {
+		if actual := isRowError(test.err); actual != test.expected {
+			t.Errorf("isRowError(%v) = %v, expected %v", test.err, actual, test.expected)
+		}
+	}
+}
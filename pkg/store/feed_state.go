@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+type FeedState struct {
+	pool *pgxpool.Pool
+}
+
+func InitFeedState(pool *pgxpool.Pool) *FeedState {
+	return &FeedState{pool}
+}
+
+var getFeedStateQuery = fmt.Sprintf(`SELECT %s FROM "FeedState" WHERE "url" = $1`, selectList(feedStateColumns))
+
+// saveFeedStateQuery upserts a feed state, now is the SQL for the current
+// time of the dialect.
+func saveFeedStateQuery(now string) string {
+	names, placeholders := insertColumns(feedStateColumns)
+	return fmt.Sprintf(`
+	INSERT INTO "FeedState" (%s, "updatedAt")
+	VALUES (%s, %s)
+	ON CONFLICT ("url") DO UPDATE SET
+		"etag" = excluded."etag",
+		"lastModified" = excluded."lastModified",
+		"updatedAt" = excluded."updatedAt"`, names, placeholders, now)
+}
+
+func (f *FeedState) GetFeedState(ctx context.Context, url string) (*models.FeedState, error) {
+	state, err := scanRow(f.pool.QueryRow(ctx, getFeedStateQuery, url), feedStateColumns)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return state, err
+}
+
+func (f *FeedState) SaveFeedState(ctx context.Context, state *models.FeedState) error {
+	_, err := f.pool.Exec(ctx, saveFeedStateQuery("now()"), insertArgs(feedStateColumns, state)...)
+	return err
+}
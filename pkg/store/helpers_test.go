@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool connects to TEST_DATABASE_URL inside a throwaway schema that is
+// dropped when the test ends. Tests using it are skipped without a database.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dbUrl := os.Getenv("TEST_DATABASE_URL")
+	if dbUrl == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("scraper_test_%d", rand.N(1_000_000_000))
+
+	admin, err := OpenDB(ctx, PoolConfig{URL: dbUrl})
+	if err != nil {
+		t.Fatalf("Unable to connect to test database: %v", err)
+	}
+	if _, err := admin.Exec(ctx, fmt.Sprintf(`CREATE SCHEMA %q`, schema)); err != nil {
+		admin.Close()
+		t.Fatalf("Unable to create test schema: %v", err)
+	}
+
+	connString, _, err := parseURL(dbUrl, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sep := "?"
+	if strings.Contains(connString, "?") {
+		sep = "&"
+	}
+	pool, err := OpenDB(ctx, PoolConfig{URL: connString + sep + "search_path=" + schema})
+	if err != nil {
+		t.Fatalf("Unable to connect to test schema: %v", err)
+	}
+
+	t.Cleanup(func() {
+		pool.Close()
+		admin.Exec(ctx, fmt.Sprintf(`DROP SCHEMA %q CASCADE`, schema))
+		admin.Close()
+	})
+
+	return pool
+}
+
+// migratedPool is a testPool with every migration applied.
+func migratedPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	pool := testPool(t)
+
+	migrator, err := NewMigrator(pool)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Unable to migrate test schema: %v", err)
+	}
+
+	return pool
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// KeywordStore is the KeywordRepository of Postgres.
+type KeywordStore struct {
+	pool *pgxpool.Pool
+}
+
+func InitKeyword(pool *pgxpool.Pool) *KeywordStore {
+	return &KeywordStore{pool: pool}
+}
+
+// upsertKeywordQuery inserts a keyword and returns its row, the stored one
+// when the value already exists. now is the SQL for the current time of the
+// dialect.
+func upsertKeywordQuery(now string) string {
+	names, placeholders := insertColumns(keywordColumns)
+	// The no-op update makes RETURNING give the existing row on a conflict
+	return fmt.Sprintf(`
+	INSERT INTO "Keyword" (%s, "updatedAt")
+	VALUES (%s, %s)
+	ON CONFLICT ("value") DO UPDATE SET "value" = excluded."value"
+	RETURNING %s`, names, placeholders, now, selectList(keywordColumns))
+}
+
+const linkKeywordQuery = `
+	INSERT INTO "_KeywordToNotice" ("A", "B") VALUES ($1, $2)
+	ON CONFLICT ("A", "B") DO NOTHING`
+
+const unlinkKeywordsQuery = `DELETE FROM "_KeywordToNotice" WHERE "B" = $1`
+
+var getNoticeKeywordsQuery = fmt.Sprintf(`
+	SELECT %s FROM "Keyword"
+	JOIN "_KeywordToNotice" ON "_KeywordToNotice"."A" = "Keyword"."id"
+	WHERE "_KeywordToNotice"."B" = $1
+	ORDER BY "value"`, selectList(keywordColumns))
+
+func newKeyword(value string) *models.Keyword {
+	return &models.Keyword{ID: uuid.New().String(), Value: value}
+}
+
+func (k *KeywordStore) UpsertKeywords(ctx context.Context, values []string) ([]*models.Keyword, error) {
+	query := upsertKeywordQuery("now()")
+
+	keywords := make([]*models.Keyword, len(values))
+	for i, value := range values {
+		keyword, err := scanRow(k.pool.QueryRow(ctx, query, insertArgs(keywordColumns, newKeyword(value))...), keywordColumns)
+		if err != nil {
+			return nil, fmt.Errorf("unable to upsert keyword %q: %w", value, err)
+		}
+		keywords[i] = keyword
+	}
+	return keywords, nil
+}
+
+func (k *KeywordStore) LinkKeywords(ctx context.Context, noticeID string, keywordIDs []string) error {
+	for _, id := range keywordIDs {
+		if _, err := k.pool.Exec(ctx, linkKeywordQuery, id, noticeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KeywordStore) SetKeywords(ctx context.Context, noticeID string, keywordIDs []string) error {
+	return pgx.BeginFunc(ctx, k.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, unlinkKeywordsQuery, noticeID); err != nil {
+			return err
+		}
+		for _, id := range keywordIDs {
+			if _, err := tx.Exec(ctx, linkKeywordQuery, id, noticeID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (k *KeywordStore) GetNoticeKeywords(ctx context.Context, noticeID string) ([]*models.Keyword, error) {
+	rows, err := k.pool.Query(ctx, getNoticeKeywordsQuery, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keywords []*models.Keyword
+	for rows.Next() {
+		keyword, err := scanRow(rows, keywordColumns)
+		if err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, keyword)
+	}
+
+	return keywords, rows.Err()
+}
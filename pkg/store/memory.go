@@ -0,0 +1,424 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// memoryStore keeps notices and sources in memory, with the same dedupe and
+// foreign key rules as the databases. It is meant for tests.
+type memoryStore struct {
+	mu      sync.Mutex
+	sources map[string]models.Source
+	notices []models.Notice
+	keys    map[[2]string]bool
+	feeds   map[string]models.FeedState
+	// keywords are keyed by value, links by keyword id and notice id
+	keywords map[string]models.Keyword
+	links    map[[2]string]bool
+	// revisions are in the order they were saved
+	revisions []models.NoticeRevision
+}
+
+// NewMemory returns a Backend that is lost when the process exits.
+func NewMemory() *Backend {
+	m := &memoryStore{
+		sources:  map[string]models.Source{},
+		keys:     map[[2]string]bool{},
+		feeds:    map[string]models.FeedState{},
+		keywords: map[string]models.Keyword{},
+		links:    map[[2]string]bool{},
+	}
+
+	return &Backend{
+		Notices:    m,
+		Sources:    m,
+		FeedStates: m,
+		Keywords:   m,
+		Migrations: memoryMigrations{},
+		close:      func() {},
+	}
+}
+
+func (m *memoryStore) CreateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var inserted []*models.Notice
+	var failed []*RowError
+	for _, notice := range notices {
+		if _, ok := m.sources[notice.SourceID]; !ok {
+			failed = append(failed, &RowError{Notice: notice, Err: fmt.Errorf("source %q doesn't exist", notice.SourceID)})
+			continue
+		}
+
+		key := [2]string{notice.Guid, notice.SourceID}
+		if m.keys[key] {
+			continue
+		}
+		m.keys[key] = true
+
+		setContentHash(notice)
+		notice.CreatedAt = time.Now()
+		m.notices = append(m.notices, *notice)
+		inserted = append(inserted, notice)
+	}
+
+	if len(failed) > 0 {
+		return inserted, &InsertError{Rows: failed, Attempted: len(notices)}
+	}
+	return inserted, nil
+}
+
+func (m *memoryStore) GetGuids(ctx context.Context, sourceId string) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	guids := map[string]bool{}
+	for _, notice := range m.notices {
+		if notice.SourceID == sourceId {
+			guids[notice.Guid] = true
+		}
+	}
+	return guids, nil
+}
+
+func (m *memoryStore) GetRecentGuids(ctx context.Context, sourceId string, since time.Time) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	guids := map[string]bool{}
+	for _, notice := range m.notices {
+		if notice.SourceID == sourceId && !notice.CreatedAt.Before(since) {
+			guids[notice.Guid] = true
+		}
+	}
+	return guids, nil
+}
+
+func (m *memoryStore) GetLatestNotices(ctx context.Context) ([]*models.Notice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	since := time.Now().Add(-24 * time.Hour)
+	notices := []*models.Notice{}
+	for _, notice := range m.notices {
+		if notice.CreatedAt.Before(since) || notice.SourceID == "Reddit" {
+			continue
+		}
+		notice := notice
+		notices = append(notices, &notice)
+	}
+
+	// Newest first, notices without a date first like Postgres sorts NULLs
+	sort.SliceStable(notices, func(i, j int) bool {
+		a, b := notices[i].PublishedDate, notices[j].PublishedDate
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.After(*b)
+	})
+
+	return notices, nil
+}
+
+func (m *memoryStore) GetDuplicateCandidates(ctx context.Context, since time.Time, title string, simHash *int64) ([]*models.Notice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	args := duplicateCandidatesArgs(title, simHash)
+	from, to := args[0].(string), args[1].(string)
+	candidate := func(notice models.Notice) bool {
+		if notice.DedupeKey != nil && *notice.DedupeKey >= from && *notice.DedupeKey < to {
+			return true
+		}
+		for band := range simHashBands {
+			if notice.SimHash != nil && *notice.SimHash>>(16*band)&65535 == args[2+band].(int64) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// m.notices are in the order they were inserted, oldest first
+	notices := []*models.Notice{}
+	for _, notice := range m.notices {
+		if notice.CreatedAt.Before(since) || notice.CanonicalID != nil || !candidate(notice) {
+			continue
+		}
+		notice := notice
+		notices = append(notices, &notice)
+	}
+	return notices, nil
+}
+
+func (m *memoryStore) SetCanonical(ctx context.Context, noticeID string, canonicalID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.notices, func(n models.Notice) bool { return n.ID == noticeID })
+	if i < 0 || noticeID == canonicalID || !slices.ContainsFunc(m.notices, func(n models.Notice) bool { return n.ID == canonicalID }) {
+		return fmt.Errorf("notice %q or %q doesn't exist", noticeID, canonicalID)
+	}
+	m.notices[i].CanonicalID = &canonicalID
+	return nil
+}
+
+func (m *memoryStore) UpdateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var updated []*models.Notice
+	for _, notice := range notices {
+		i := slices.IndexFunc(m.notices, func(n models.Notice) bool {
+			return n.Guid == notice.Guid && n.SourceID == notice.SourceID
+		})
+		if i < 0 {
+			continue
+		}
+
+		stored := m.notices[i]
+		revision := revise(&stored, notice)
+		if revision == nil {
+			continue
+		}
+		now := time.Now()
+		revision.CreatedAt = now
+		m.revisions = append(m.revisions, *revision)
+
+		notice.UpdatedAt = &now
+		m.notices[i] = *notice
+		updated = append(updated, notice)
+	}
+	return updated, nil
+}
+
+func (m *memoryStore) GetRevisions(ctx context.Context, noticeID string) ([]*models.NoticeRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revisions := []*models.NoticeRevision{}
+	for _, revision := range m.revisions {
+		if revision.NoticeID == noticeID {
+			revision := revision
+			revisions = append(revisions, &revision)
+		}
+	}
+	return revisions, nil
+}
+
+func (m *memoryStore) GetSourceByName(ctx context.Context, name string) (*models.Source, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, ok := m.sources[name]
+	if !ok {
+		return nil, fmt.Errorf("source %q not found", name)
+	}
+	return &source, nil
+}
+
+func (m *memoryStore) EnsureSource(ctx context.Context, name string, description string, homepage string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sources[name]; ok {
+		return nil
+	}
+
+	now := time.Now()
+	m.sources[name] = models.Source{
+		Name:        name,
+		Description: &description,
+		Homepage:    &homepage,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	return nil
+}
+
+func (m *memoryStore) GetFeedState(ctx context.Context, url string) (*models.FeedState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.feeds[url]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (m *memoryStore) SaveFeedState(ctx context.Context, state *models.FeedState) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *state
+	saved.UpdatedAt = time.Now()
+	m.feeds[state.URL] = saved
+	return nil
+}
+
+func (m *memoryStore) UpsertKeywords(ctx context.Context, values []string) ([]*models.Keyword, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keywords := make([]*models.Keyword, len(values))
+	for i, value := range values {
+		keyword, ok := m.keywords[value]
+		if !ok {
+			keyword = *newKeyword(value)
+			keyword.CreatedAt = time.Now()
+			keyword.UpdatedAt = keyword.CreatedAt
+			m.keywords[value] = keyword
+		}
+		keywords[i] = &keyword
+	}
+	return keywords, nil
+}
+
+func (m *memoryStore) LinkKeywords(ctx context.Context, noticeID string, keywordIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !slices.ContainsFunc(m.notices, func(n models.Notice) bool { return n.ID == noticeID }) {
+		return fmt.Errorf("notice %q doesn't exist", noticeID)
+	}
+	for _, id := range keywordIDs {
+		if m.keywordByID(id) == nil {
+			return fmt.Errorf("keyword %q doesn't exist", id)
+		}
+		m.links[[2]string{id, noticeID}] = true
+	}
+	return nil
+}
+
+func (m *memoryStore) SetKeywords(ctx context.Context, noticeID string, keywordIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !slices.ContainsFunc(m.notices, func(n models.Notice) bool { return n.ID == noticeID }) {
+		return fmt.Errorf("notice %q doesn't exist", noticeID)
+	}
+	for _, id := range keywordIDs {
+		if m.keywordByID(id) == nil {
+			return fmt.Errorf("keyword %q doesn't exist", id)
+		}
+	}
+	for link := range m.links {
+		if link[1] == noticeID {
+			delete(m.links, link)
+		}
+	}
+	for _, id := range keywordIDs {
+		m.links[[2]string{id, noticeID}] = true
+	}
+	return nil
+}
+
+func (m *memoryStore) GetNoticeKeywords(ctx context.Context, noticeID string) ([]*models.Keyword, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keywords []*models.Keyword
+	for link := range m.links {
+		if link[1] == noticeID {
+			keywords = append(keywords, m.keywordByID(link[0]))
+		}
+	}
+	sort.Slice(keywords, func(i, j int) bool { return keywords[i].Value < keywords[j].Value })
+	return keywords, nil
+}
+
+func (m *memoryStore) keywordByID(id string) *models.Keyword {
+	for _, keyword := range m.keywords {
+		if keyword.ID == id {
+			return &keyword
+		}
+	}
+	return nil
+}
+
+// memoryMigrations has nothing to migrate.
+type memoryMigrations struct{}
+
+func (memoryMigrations) Up(ctx context.Context) ([]Migration, error) {
+	return nil, nil
+}
+
+func (memoryMigrations) Down(ctx context.Context, steps int) ([]Migration, error) {
+	return nil, nil
+}
+
+func (memoryMigrations) Status(ctx context.Context) ([]MigrationStatus, error) {
+	return nil, nil
+}
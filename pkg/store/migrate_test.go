@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("Expected migration %d to have version %d, got %d", i, i+1, m.Version)
+		}
+		// Only the migration adopting the Prisma tables is irreversible
+		if irreversible := m.Down == ""; irreversible != (m.Version == 1) {
+			t.Errorf("Expected only migration 1 to be irreversible, got %d_%s", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+
+	migrator, err := NewMigrator(pool)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tableCount := func() int {
+		var count int
+		err := pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = current_schema()
+		AND table_name IN ('Notice', 'Source', 'Keyword', '_KeywordToNotice')`).Scan(&count)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return count
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(applied) != len(migrator.migrations) {
+		t.Errorf("Expected %d migrations to be applied, got %d", len(migrator.migrations), len(applied))
+	}
+	if count := tableCount(); count != 4 {
+		t.Errorf("Expected 4 tables, got %d", count)
+	}
+
+	applied, err = migrator.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Expected second run to apply nothing, applied %d: %v", len(applied), err)
+	}
+
+	// Rolling back stops at 0001, which would drop the stored notices
+	rolledBack, err := migrator.Down(ctx, len(migrator.migrations))
+	if !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("Expected ErrIrreversible, got %v", err)
+	}
+	if len(rolledBack) != len(migrator.migrations)-1 {
+		t.Errorf("Expected every migration but the first to be rolled back, got %d", len(rolledBack))
+	}
+	if count := tableCount(); count != 4 {
+		t.Errorf("Expected the 4 tables to be kept, got %d", count)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range statuses {
+		if (s.AppliedAt != nil) != (s.Version == 1) {
+			t.Errorf("Expected only migration 1 to be applied, got %d applied at %v", s.Version, s.AppliedAt)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+-- Tables as created by schema.prisma. Everything is IF NOT EXISTS so a
+-- database that was set up with Prisma can adopt the Go migrations.
+CREATE TABLE IF NOT EXISTS "Source" (
+    "name" TEXT NOT NULL,
+    "description" TEXT DEFAULT '',
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    "homepage" TEXT DEFAULT '',
+    "iconUrl" TEXT DEFAULT '',
+    CONSTRAINT "Source_pkey" PRIMARY KEY ("name")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "Source_name_key" ON "Source"("name");
+
+CREATE TABLE IF NOT EXISTS "Notice" (
+    "id" TEXT NOT NULL,
+    "title" TEXT NOT NULL,
+    "body" TEXT NOT NULL,
+    "url" TEXT NOT NULL,
+    "authorName" TEXT NOT NULL,
+    "authorUrl" TEXT NOT NULL,
+    "imageUrl" TEXT,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3),
+    "sourceId" TEXT NOT NULL,
+    "raw" TEXT NOT NULL,
+    "guid" TEXT,
+    "publishedDate" TIMESTAMP(3),
+    CONSTRAINT "Notice_pkey" PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "Notice_guid_sourceId_key" ON "Notice"("guid", "sourceId");
+
+CREATE TABLE IF NOT EXISTS "Keyword" (
+    "id" TEXT NOT NULL,
+    "value" TEXT NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    CONSTRAINT "Keyword_pkey" PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "Keyword_value_key" ON "Keyword"("value");
+
+-- Prisma's implicit many to many table, A is the Keyword and B the Notice
+CREATE TABLE IF NOT EXISTS "_KeywordToNotice" (
+    "A" TEXT NOT NULL,
+    "B" TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "_KeywordToNotice_AB_unique" ON "_KeywordToNotice"("A", "B");
+CREATE INDEX IF NOT EXISTS "_KeywordToNotice_B_index" ON "_KeywordToNotice"("B");
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'Notice_sourceId_fkey' AND connamespace = current_schema()::regnamespace) THEN
+        ALTER TABLE "Notice" ADD CONSTRAINT "Notice_sourceId_fkey"
+            FOREIGN KEY ("sourceId") REFERENCES "Source"("name") ON DELETE RESTRICT ON UPDATE CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = '_KeywordToNotice_A_fkey' AND connamespace = current_schema()::regnamespace) THEN
+        ALTER TABLE "_KeywordToNotice" ADD CONSTRAINT "_KeywordToNotice_A_fkey"
+            FOREIGN KEY ("A") REFERENCES "Keyword"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = '_KeywordToNotice_B_fkey' AND connamespace = current_schema()::regnamespace) THEN
+        ALTER TABLE "_KeywordToNotice" ADD CONSTRAINT "_KeywordToNotice_B_fkey"
+            FOREIGN KEY ("B") REFERENCES "Notice"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+    END IF;
+END $$;
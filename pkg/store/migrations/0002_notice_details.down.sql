@@ -0,0 +1,5 @@
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "salary";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "remote";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "location";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "role";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "company";
@@ -0,0 +1,6 @@
+-- Fields parsed out of Hacker News hiring headers
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "company" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "role" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "location" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "remote" BOOLEAN;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "salary" TEXT;
@@ -0,0 +1,9 @@
+-- Validators of the last response of every feed, sent back on the next
+-- request so an unchanged feed answers 304 Not Modified
+CREATE TABLE IF NOT EXISTS "FeedState" (
+    "url" TEXT NOT NULL,
+    "etag" TEXT,
+    "lastModified" TEXT,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    CONSTRAINT "FeedState_pkey" PRIMARY KEY ("url")
+);
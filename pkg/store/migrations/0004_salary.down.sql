@@ -0,0 +1,6 @@
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "salaryAnnualMax";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "salaryAnnualMin";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "salaryPeriod";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "salaryCurrency";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "salaryMax";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "salaryMin";
@@ -0,0 +1,8 @@
+-- Salary parsed out of the notice, the annual columns are the range paid
+-- over a year so notices paid by the hour can be compared
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "salaryMin" DOUBLE PRECISION;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "salaryMax" DOUBLE PRECISION;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "salaryCurrency" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "salaryPeriod" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "salaryAnnualMin" DOUBLE PRECISION;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "salaryAnnualMax" DOUBLE PRECISION;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "Notice_workplace_idx";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "places";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "regions";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "workplace";
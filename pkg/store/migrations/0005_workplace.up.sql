@@ -0,0 +1,6 @@
+-- Where the work of a notice happens, see pkg/workplace. Regions and places
+-- are comma separated.
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "workplace" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "regions" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "places" TEXT;
+CREATE INDEX IF NOT EXISTS "Notice_workplace_idx" ON "Notice"("workplace");
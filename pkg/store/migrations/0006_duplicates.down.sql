@@ -0,0 +1,10 @@
+ALTER TABLE "Notice" DROP CONSTRAINT IF EXISTS "Notice_canonicalId_fkey";
+DROP INDEX IF EXISTS "Notice_canonicalId_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band3_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band2_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band1_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band0_idx";
+DROP INDEX IF EXISTS "Notice_dedupeKey_idx";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "canonicalId";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "simhash";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "dedupeKey";
@@ -0,0 +1,23 @@
+-- Notices that are the same job as one found earlier, on another board or
+-- reposted, see pkg/dedupe. The first notice of a job has no canonicalId.
+-- Duplicates are looked up instead of every recent notice being compared:
+-- the dedupe key is "title|company" so the keys of a title are a range of
+-- the index, compared in byte order, and each 16 bit band of the SimHash
+-- gets an index of its own.
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "dedupeKey" TEXT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "simhash" BIGINT;
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "canonicalId" TEXT;
+CREATE INDEX IF NOT EXISTS "Notice_dedupeKey_idx" ON "Notice"("dedupeKey" COLLATE "C");
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band0_idx" ON "Notice"(("simhash" & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band1_idx" ON "Notice"((("simhash" >> 16) & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band2_idx" ON "Notice"((("simhash" >> 32) & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band3_idx" ON "Notice"((("simhash" >> 48) & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_canonicalId_idx" ON "Notice"("canonicalId");
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'Notice_canonicalId_fkey' AND connamespace = current_schema()::regnamespace) THEN
+        ALTER TABLE "Notice" ADD CONSTRAINT "Notice_canonicalId_fkey"
+            FOREIGN KEY ("canonicalId") REFERENCES "Notice"("id") ON DELETE SET NULL ON UPDATE CASCADE;
+    END IF;
+END $$;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "NoticeRevision";
+ALTER TABLE "Notice" DROP COLUMN IF EXISTS "contentHash";
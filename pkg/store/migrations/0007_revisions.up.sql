@@ -0,0 +1,23 @@
+-- Notices found again with another content hash are updated, their previous
+-- version is kept in "NoticeRevision"
+ALTER TABLE "Notice" ADD COLUMN IF NOT EXISTS "contentHash" TEXT;
+
+CREATE TABLE IF NOT EXISTS "NoticeRevision" (
+    "id" TEXT NOT NULL,
+    "noticeId" TEXT NOT NULL,
+    "title" TEXT NOT NULL,
+    "body" TEXT NOT NULL,
+    "raw" TEXT NOT NULL,
+    "contentHash" TEXT,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "NoticeRevision_pkey" PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "NoticeRevision_noticeId_idx" ON "NoticeRevision"("noticeId");
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'NoticeRevision_noticeId_fkey' AND connamespace = current_schema()::regnamespace) THEN
+        ALTER TABLE "NoticeRevision" ADD CONSTRAINT "NoticeRevision_noticeId_fkey"
+            FOREIGN KEY ("noticeId") REFERENCES "Notice"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+    END IF;
+END $$;
@@ -0,0 +1,43 @@
+-- The same tables as the Postgres migrations. Timestamps are stored as
+-- UTC text, which sorts and compares in order.
+CREATE TABLE IF NOT EXISTS "Source" (
+    "name" TEXT NOT NULL PRIMARY KEY,
+    "description" TEXT DEFAULT '',
+    "createdAt" TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    "updatedAt" TIMESTAMP NOT NULL,
+    "homepage" TEXT DEFAULT '',
+    "iconUrl" TEXT DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS "Notice" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "title" TEXT NOT NULL,
+    "body" TEXT NOT NULL,
+    "url" TEXT NOT NULL,
+    "authorName" TEXT NOT NULL,
+    "authorUrl" TEXT NOT NULL,
+    "imageUrl" TEXT,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    "updatedAt" TIMESTAMP,
+    "sourceId" TEXT NOT NULL REFERENCES "Source"("name") ON DELETE RESTRICT ON UPDATE CASCADE,
+    "raw" TEXT NOT NULL,
+    "guid" TEXT,
+    "publishedDate" TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "Notice_guid_sourceId_key" ON "Notice"("guid", "sourceId");
+
+CREATE TABLE IF NOT EXISTS "Keyword" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "value" TEXT NOT NULL,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    "updatedAt" TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "Keyword_value_key" ON "Keyword"("value");
+
+-- A is the Keyword and B the Notice, as in Prisma's implicit table
+CREATE TABLE IF NOT EXISTS "_KeywordToNotice" (
+    "A" TEXT NOT NULL REFERENCES "Keyword"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    "B" TEXT NOT NULL REFERENCES "Notice"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "_KeywordToNotice_AB_unique" ON "_KeywordToNotice"("A", "B");
+CREATE INDEX IF NOT EXISTS "_KeywordToNotice_B_index" ON "_KeywordToNotice"("B");
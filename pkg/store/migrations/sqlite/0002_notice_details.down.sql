@@ -0,0 +1,5 @@
+ALTER TABLE "Notice" DROP COLUMN "salary";
+ALTER TABLE "Notice" DROP COLUMN "remote";
+ALTER TABLE "Notice" DROP COLUMN "location";
+ALTER TABLE "Notice" DROP COLUMN "role";
+ALTER TABLE "Notice" DROP COLUMN "company";
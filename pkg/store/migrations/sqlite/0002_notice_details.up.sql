@@ -0,0 +1,6 @@
+-- Fields parsed out of Hacker News hiring headers
+ALTER TABLE "Notice" ADD COLUMN "company" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "role" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "location" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "remote" BOOLEAN;
+ALTER TABLE "Notice" ADD COLUMN "salary" TEXT;
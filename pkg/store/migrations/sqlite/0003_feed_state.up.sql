@@ -0,0 +1,8 @@
+-- Validators of the last response of every feed, sent back on the next
+-- request so an unchanged feed answers 304 Not Modified
+CREATE TABLE IF NOT EXISTS "FeedState" (
+    "url" TEXT NOT NULL PRIMARY KEY,
+    "etag" TEXT,
+    "lastModified" TEXT,
+    "updatedAt" TIMESTAMP NOT NULL
+);
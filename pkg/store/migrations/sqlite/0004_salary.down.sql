@@ -0,0 +1,6 @@
+ALTER TABLE "Notice" DROP COLUMN "salaryAnnualMax";
+ALTER TABLE "Notice" DROP COLUMN "salaryAnnualMin";
+ALTER TABLE "Notice" DROP COLUMN "salaryPeriod";
+ALTER TABLE "Notice" DROP COLUMN "salaryCurrency";
+ALTER TABLE "Notice" DROP COLUMN "salaryMax";
+ALTER TABLE "Notice" DROP COLUMN "salaryMin";
@@ -0,0 +1,8 @@
+-- Salary parsed out of the notice, the annual columns are the range paid
+-- over a year so notices paid by the hour can be compared
+ALTER TABLE "Notice" ADD COLUMN "salaryMin" REAL;
+ALTER TABLE "Notice" ADD COLUMN "salaryMax" REAL;
+ALTER TABLE "Notice" ADD COLUMN "salaryCurrency" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "salaryPeriod" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "salaryAnnualMin" REAL;
+ALTER TABLE "Notice" ADD COLUMN "salaryAnnualMax" REAL;
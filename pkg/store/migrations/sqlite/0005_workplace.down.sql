@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "Notice_workplace_idx";
+ALTER TABLE "Notice" DROP COLUMN "places";
+ALTER TABLE "Notice" DROP COLUMN "regions";
+ALTER TABLE "Notice" DROP COLUMN "workplace";
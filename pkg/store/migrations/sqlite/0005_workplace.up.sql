@@ -0,0 +1,6 @@
+-- Where the work of a notice happens, see pkg/workplace. Regions and places
+-- are comma separated.
+ALTER TABLE "Notice" ADD COLUMN "workplace" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "regions" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "places" TEXT;
+CREATE INDEX IF NOT EXISTS "Notice_workplace_idx" ON "Notice"("workplace");
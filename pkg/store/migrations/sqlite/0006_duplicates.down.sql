@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "Notice_canonicalId_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band3_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band2_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band1_idx";
+DROP INDEX IF EXISTS "Notice_simhash_band0_idx";
+DROP INDEX IF EXISTS "Notice_dedupeKey_idx";
+ALTER TABLE "Notice" DROP COLUMN "canonicalId";
+ALTER TABLE "Notice" DROP COLUMN "simhash";
+ALTER TABLE "Notice" DROP COLUMN "dedupeKey";
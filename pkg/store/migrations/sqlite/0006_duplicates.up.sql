@@ -0,0 +1,16 @@
+-- Notices that are the same job as one found earlier, on another board or
+-- reposted, see pkg/dedupe. The first notice of a job has no canonicalId.
+-- SQLite can't drop a column with a foreign key, so the canonical notice is
+-- checked when it is set instead.
+-- Duplicates are looked up instead of every recent notice being compared:
+-- the dedupe key is "title|company" so the keys of a title are a range of
+-- its index, and each 16 bit band of the SimHash gets an index of its own.
+ALTER TABLE "Notice" ADD COLUMN "dedupeKey" TEXT;
+ALTER TABLE "Notice" ADD COLUMN "simhash" INTEGER;
+ALTER TABLE "Notice" ADD COLUMN "canonicalId" TEXT;
+CREATE INDEX IF NOT EXISTS "Notice_dedupeKey_idx" ON "Notice"("dedupeKey");
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band0_idx" ON "Notice"(("simhash" & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band1_idx" ON "Notice"((("simhash" >> 16) & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band2_idx" ON "Notice"((("simhash" >> 32) & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_simhash_band3_idx" ON "Notice"((("simhash" >> 48) & 65535));
+CREATE INDEX IF NOT EXISTS "Notice_canonicalId_idx" ON "Notice"("canonicalId");
@@ -0,0 +1,14 @@
+-- Notices found again with another content hash are updated, their previous
+-- version is kept in "NoticeRevision"
+ALTER TABLE "Notice" ADD COLUMN "contentHash" TEXT;
+
+CREATE TABLE IF NOT EXISTS "NoticeRevision" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "noticeId" TEXT NOT NULL REFERENCES "Notice"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    "title" TEXT NOT NULL,
+    "body" TEXT NOT NULL,
+    "raw" TEXT NOT NULL,
+    "contentHash" TEXT,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX IF NOT EXISTS "NoticeRevision_noticeId_idx" ON "NoticeRevision"("noticeId");
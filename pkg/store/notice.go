@@ -2,9 +2,15 @@ package store
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
 )
@@ -12,126 +18,269 @@ import (
 const tableName = "Notice"
 
 type NoticeStore struct {
-	conn *pgx.Conn
-}
-
-func InitNotice(conn *pgx.Conn) *NoticeStore {
-	return &NoticeStore{conn: conn}
-}
-
-func (n *NoticeStore) CreateNotices(notices []*models.Notice) error {
-	query := fmt.Sprintf(`
-	INSERT INTO "%s" (
-		id,
-		title,
-		body,
-		url,
-		"authorName",
-		"authorUrl",
-		"imageUrl",
-		"sourceId",
-		raw,
-		guid,
-		"publishedDate"
-	) VALUES (
-		$1,
-		$2,
-		$3,
-		$4,
-		$5,
-		$6,
-		$7,
-		$8,
-		$9,
-		$10,
-		$11
-	) ON CONFLICT (guid, "sourceId") DO NOTHING`, tableName)
+	pool *pgxpool.Pool
+}
+
+func InitNotice(pool *pgxpool.Pool) *NoticeStore {
+	return &NoticeStore{pool: pool}
+}
+
+var insertNoticeQuery = func() string {
+	names, placeholders := insertColumns(noticeColumns)
+	return fmt.Sprintf(`
+	INSERT INTO "%s" (%s) VALUES (%s)
+	ON CONFLICT (guid, "sourceId") DO NOTHING
+	RETURNING "createdAt"`, tableName, names, placeholders)
+}()
+
+func noticeArgs(notice *models.Notice) []any {
+	setContentHash(notice)
+	return insertArgs(noticeColumns, notice)
+}
+
+// contentHash hashes the title and body of notice, which is what an edit
+// changes. Raw isn't hashed, it holds counters like scores that change all
+// the time.
+func contentHash(notice *models.Notice) string {
+	sum := sha256.Sum256([]byte(notice.Title + "\x00" + notice.Body))
+	return hex.EncodeToString(sum[:])
+}
+
+func setContentHash(notice *models.Notice) {
+	hash := contentHash(notice)
+	notice.ContentHash = &hash
+}
+
+// revise compares notice with its stored version and returns the revision
+// to save when the content changed, nil when it didn't. Either way notice
+// takes the id, times and canonical notice of the stored one.
+func revise(stored *models.Notice, notice *models.Notice) *models.NoticeRevision {
+	notice.ID = stored.ID
+	notice.CreatedAt = stored.CreatedAt
+	notice.UpdatedAt = stored.UpdatedAt
+	notice.CanonicalID = stored.CanonicalID
+	setContentHash(notice)
+
+	// Notices stored before hashes were kept are hashed now
+	previous := stored.ContentHash
+	if previous == nil {
+		hash := contentHash(stored)
+		previous = &hash
+	}
+	if *previous == *notice.ContentHash {
+		return nil
+	}
+
+	return &models.NoticeRevision{
+		ID:          uuid.New().String(),
+		NoticeID:    stored.ID,
+		Title:       stored.Title,
+		Body:        stored.Body,
+		Raw:         stored.Raw,
+		ContentHash: previous,
+	}
+}
+
+// noticeKey is the source and guid a notice is stored under.
+type noticeKey struct {
+	sourceID string
+	guid     string
+}
+
+// storedHashesQuery returns the content hash of every stored notice whose
+// guid and source are in $1 and $2.
+var storedHashesQuery = fmt.Sprintf(`
+	SELECT "sourceId", guid, "contentHash" FROM "%s"
+	WHERE (guid, "sourceId") IN (SELECT * FROM unnest($1::text[], $2::text[]))`, tableName)
+
+// scanHashes reads the rows of a stored hashes query.
+func scanHashes(rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}) (map[noticeKey]*string, error) {
+	hashes := map[noticeKey]*string{}
+	for rows.Next() {
+		var key noticeKey
+		var hash *string
+		if err := rows.Scan(&key.sourceID, &key.guid, &hash); err != nil {
+			return nil, err
+		}
+		hashes[key] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// changedNotices returns the notices whose content hash isn't the one in
+// hashes, which holds the stored hashes by source and guid. Notices that
+// aren't stored are left out, the ones stored without a hash are kept to be
+// compared in full.
+func changedNotices(notices []*models.Notice, hashes map[noticeKey]*string) []*models.Notice {
+	var changed []*models.Notice
+	for _, notice := range notices {
+		stored, ok := hashes[noticeKey{notice.SourceID, notice.Guid}]
+		if !ok {
+			continue
+		}
+		if stored == nil || *stored != contentHash(notice) {
+			changed = append(changed, notice)
+		}
+	}
+	return changed
+}
+
+var storedNoticeQuery = fmt.Sprintf(`
+	SELECT %s FROM "%s" WHERE guid = $1 AND "sourceId" = $2`, selectList(noticeColumns), tableName)
+
+// updateNoticeQuery sets every column but the keys of the notice with id $1.
+// now is the SQL for the current time of the dialect.
+func updateNoticeQuery(now string) string {
+	return fmt.Sprintf(`
+	UPDATE "%s" SET %s, "updatedAt" = %s
+	WHERE id = $1
+	RETURNING "updatedAt"`, tableName, updateSet(noticeColumns, noticeKeyColumns, 2), now)
+}
+
+func updateNoticeArgs(notice *models.Notice) []any {
+	return append([]any{notice.ID}, updateArgs(noticeColumns, noticeKeyColumns, notice)...)
+}
+
+var insertRevisionQuery = func() string {
+	names, placeholders := insertColumns(revisionColumns)
+	return fmt.Sprintf(`INSERT INTO "NoticeRevision" (%s) VALUES (%s)`, names, placeholders)
+}()
+
+var getRevisionsQuery = fmt.Sprintf(`
+	SELECT %s FROM "NoticeRevision" WHERE "noticeId" = $1
+	ORDER BY "createdAt", id`, selectList(revisionColumns))
 
+// CreateNotices inserts notices, skipping the ones already stored for their
+// source, and returns the notices that were actually new. Notices that fail
+// to insert don't stop the rest, they are reported in an *InsertError.
+func (n *NoticeStore) CreateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	inserted, err := n.insertBatch(ctx, notices)
+	if err == nil {
+		return inserted, nil
+	}
+	if !isRowError(err) {
+		return nil, err
+	}
+
+	// The batch runs in a single implicit transaction, so one failing row
+	// rolls back every other row. Insert them one at a time instead to find
+	// out which ones failed and keep the rest.
+	errorHandler.HandleErrorWithSection(err, "Batch insert failed, inserting notices one at a time", "Database")
+	return n.insertEach(ctx, notices)
+}
+
+func (n *NoticeStore) insertBatch(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
 	batch := &pgx.Batch{}
+	for _, notice := range notices {
+		batch.Queue(insertNoticeQuery, noticeArgs(notice)...)
+	}
 
+	br := n.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	var inserted []*models.Notice
 	for _, notice := range notices {
-		batch.Queue(
-			query,
-			notice.ID,
-			notice.Title,
-			notice.Body,
-			notice.URL,
-			notice.AuthorName,
-			notice.AuthorURL,
-			notice.ImageURL,
-			notice.SourceID,
-			notice.Raw,
-			notice.Guid,
-			notice.PublishedDate,
-		)
-	}
-
-	br := n.conn.SendBatch(context.Background(), batch)
-	_, err := br.Exec()
-	if err != nil {
-		return err
+		isNew, err := scanInserted(br.QueryRow(), notice)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			inserted = append(inserted, notice)
+		}
 	}
-	return br.Close()
+
+	return inserted, br.Close()
 }
 
-func (n *NoticeStore) GetCount() int {
-	var count int
-	err := n.conn.QueryRow(context.Background(), fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, tableName)).Scan(&count)
+func (n *NoticeStore) insertEach(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	var inserted []*models.Notice
+	var failed []*RowError
+
+	for _, notice := range notices {
+		row := n.pool.QueryRow(ctx, insertNoticeQuery, noticeArgs(notice)...)
+		isNew, err := scanInserted(row, notice)
+		if err != nil {
+			failed = append(failed, &RowError{Notice: notice, Err: err})
+			continue
+		}
+		if isNew {
+			inserted = append(inserted, notice)
+		}
+	}
+
+	if len(failed) > 0 {
+		return inserted, &InsertError{Rows: failed, Attempted: len(notices)}
+	}
+	return inserted, nil
+}
+
+// scanInserted reads the result of an insert. Conflicts return no row, which
+// means the notice is already stored.
+func scanInserted(row pgx.Row, notice *models.Notice) (bool, error) {
+	var createdAt time.Time
+	err := row.Scan(&createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
 	if err != nil {
-		return 0
+		return false, err
 	}
-	return count
+
+	notice.CreatedAt = createdAt
+	return true, nil
 }
 
-func (n *NoticeStore) GetLatest(count int) []models.Notice {
-	var notices []models.Notice
-	rows, err := n.conn.Query(context.Background(), fmt.Sprintf(`SELECT * FROM "%s" ORDER BY "createdAt" DESC LIMIT $1`, tableName), count)
+// GetGuids returns the set of guids already stored for sourceId.
+func (n *NoticeStore) GetGuids(ctx context.Context, sourceId string) (map[string]bool, error) {
+	rows, err := n.pool.Query(ctx, fmt.Sprintf(`SELECT guid FROM "%s" WHERE "sourceId" = $1 AND guid IS NOT NULL`, tableName), sourceId)
 	if err != nil {
-		errorHandler.HandleErrorWithSection(err, "Error querying latest notices", "Database")
-		return nil
+		return nil, err
 	}
 	defer rows.Close()
 
+	guids := map[string]bool{}
 	for rows.Next() {
-		var notice models.Notice
-		err := rows.Scan(
-			&notice.ID,
-			&notice.Title,
-			&notice.Body,
-			&notice.URL,
-			&notice.AuthorName,
-			&notice.AuthorURL,
-			&notice.ImageURL,
-			&notice.CreatedAt,
-			&notice.UpdatedAt,
-			&notice.SourceID,
-			&notice.Raw,
-			&notice.Guid,
-			&notice.PublishedDate,
-		)
-		if err != nil {
-			errorHandler.HandleErrorWithSection(err, "Error scanning row", "Database")
-			return nil
+		var guid string
+		if err := rows.Scan(&guid); err != nil {
+			return nil, err
 		}
-		notices = append(notices, notice)
+		guids[guid] = true
 	}
 
-	err = rows.Err()
+	return guids, rows.Err()
+}
+
+// GetRecentGuids returns the set of guids stored for sourceId since.
+func (n *NoticeStore) GetRecentGuids(ctx context.Context, sourceId string, since time.Time) (map[string]bool, error) {
+	rows, err := n.pool.Query(ctx, fmt.Sprintf(`SELECT guid FROM "%s" WHERE "sourceId" = $1 AND guid IS NOT NULL AND "createdAt" >= $2`, tableName), sourceId, since)
 	if err != nil {
-		errorHandler.HandleErrorWithSection(err, "Error iterating rows", "Database")
-		return nil
+		return nil, err
 	}
+	defer rows.Close()
 
-	return notices
+	guids := map[string]bool{}
+	for rows.Next() {
+		var guid string
+		if err := rows.Scan(&guid); err != nil {
+			return nil, err
+		}
+		guids[guid] = true
+	}
+
+	return guids, rows.Err()
 }
 
-func (n *NoticeStore) GetLatestNotices() ([]*models.Notice, error) {
-	rows, err := n.conn.Query(context.Background(), `
-	SELECT * FROM "Notice"
+func (n *NoticeStore) GetLatestNotices(ctx context.Context) ([]*models.Notice, error) {
+	rows, err := n.pool.Query(ctx, fmt.Sprintf(`
+	SELECT %s FROM "%s"
 	WHERE "createdAt" >= (now() - interval '1 day')
 	AND "sourceId" != 'Reddit'
 	ORDER BY "publishedDate" DESC
-	`)
+	`, selectList(noticeColumns), tableName))
 	if err != nil {
 		return nil, err
 	}
@@ -139,25 +288,183 @@ func (n *NoticeStore) GetLatestNotices() ([]*models.Notice, error) {
 
 	notices := []*models.Notice{}
 	for rows.Next() {
-		var notice models.Notice
-		// Assume models.Notice has the same fields as your Prisma schema
-		if err := rows.Scan(&notice.ID,
-			&notice.Title,
-			&notice.Body,
-			&notice.URL,
-			&notice.AuthorName,
-			&notice.AuthorURL,
-			&notice.ImageURL,
-			&notice.CreatedAt,
-			&notice.UpdatedAt,
-			&notice.SourceID,
-			&notice.Raw,
-			&notice.Guid,
-			&notice.PublishedDate); err != nil {
+		notice, err := scanRow(rows, noticeColumns)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, notice)
+	}
+
+	return notices, rows.Err()
+}
+
+// simHashBands is how many bands a SimHash is split into to be looked up,
+// every band has an index of its own.
+const simHashBands = 4
+
+// duplicateCandidatesQuery looks up the dedupe keys of a title as the range
+// between "title|" and "title}", in byte order so the index can be used,
+// and each band with the expression of its index.
+var duplicateCandidatesQuery = fmt.Sprintf(`
+	SELECT %s FROM "%s"
+	WHERE "createdAt" >= $1 AND "canonicalId" IS NULL AND (
+		("dedupeKey" COLLATE "C" >= $2 AND "dedupeKey" COLLATE "C" < $3)
+		OR ("simhash" & 65535) = $4
+		OR (("simhash" >> 16) & 65535) = $5
+		OR (("simhash" >> 32) & 65535) = $6
+		OR (("simhash" >> 48) & 65535) = $7
+	)
+	ORDER BY "createdAt", id
+	`, selectList(noticeColumns), tableName)
+
+// duplicateCandidatesArgs returns the bounds of the dedupe keys of title,
+// which are "title|company", and the bands of simHash. The bands of a
+// missing SimHash are -1, which no band equals.
+func duplicateCandidatesArgs(title string, simHash *int64) []any {
+	args := []any{title + "|", title + "}"}
+	if title == "" {
+		args = []any{"", ""}
+	}
+	for band := range simHashBands {
+		value := int64(-1)
+		if simHash != nil {
+			value = *simHash >> (16 * band) & 65535
+		}
+		args = append(args, value)
+	}
+	return args
+}
+
+// GetDuplicateCandidates returns the notices stored since that aren't
+// duplicates and share the title or a band of the SimHash of a notice,
+// oldest first.
+func (n *NoticeStore) GetDuplicateCandidates(ctx context.Context, since time.Time, title string, simHash *int64) ([]*models.Notice, error) {
+	args := append([]any{since}, duplicateCandidatesArgs(title, simHash)...)
+	rows, err := n.pool.Query(ctx, duplicateCandidatesQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notices := []*models.Notice{}
+	for rows.Next() {
+		notice, err := scanRow(rows, noticeColumns)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, notice)
+	}
+
+	return notices, rows.Err()
+}
+
+// setCanonicalQuery only updates the notice when the canonical one exists,
+// SQLite has no foreign key to check it.
+var setCanonicalQuery = fmt.Sprintf(`
+	UPDATE "%[1]s" SET "canonicalId" = $2
+	WHERE id = $1 AND $1 != $2 AND EXISTS (SELECT 1 FROM "%[1]s" WHERE id = $2)
+	`, tableName)
+
+// SetCanonical marks a notice as a duplicate of canonicalID.
+func (n *NoticeStore) SetCanonical(ctx context.Context, noticeID string, canonicalID string) error {
+	tag, err := n.pool.Exec(ctx, setCanonicalQuery, noticeID, canonicalID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("notice %q or %q doesn't exist", noticeID, canonicalID)
+	}
+	return nil
+}
+
+// UpdateNotices saves the notices whose title or body changed since they
+// were stored, keeping the previous version as a revision, and returns them.
+// The stored hashes are compared in a single query first, so only the
+// changed notices are updated in a transaction of their own. Notices that
+// aren't stored or didn't change are skipped. Notices that fail to update
+// are reported in an *InsertError.
+func (n *NoticeStore) UpdateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	guids := make([]string, len(notices))
+	sources := make([]string, len(notices))
+	for i, notice := range notices {
+		guids[i], sources[i] = notice.Guid, notice.SourceID
+	}
+	rows, err := n.pool.Query(ctx, storedHashesQuery, guids, sources)
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := scanHashes(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []*models.Notice
+	var failed []*RowError
+
+	for _, notice := range changedNotices(notices, hashes) {
+		changed, err := n.update(ctx, notice)
+		if err != nil {
+			failed = append(failed, &RowError{Notice: notice, Err: err})
+			continue
+		}
+		if changed {
+			updated = append(updated, notice)
+		}
+	}
+
+	if len(failed) > 0 {
+		return updated, &InsertError{Rows: failed, Attempted: len(notices), Update: true}
+	}
+	return updated, nil
+}
+
+func (n *NoticeStore) update(ctx context.Context, notice *models.Notice) (bool, error) {
+	tx, err := n.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locked so two runs don't both save the same revision
+	stored, err := scanRow(tx.QueryRow(ctx, storedNoticeQuery+" FOR UPDATE", notice.Guid, notice.SourceID), noticeColumns)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	revision := revise(stored, notice)
+	if revision == nil {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, insertRevisionQuery, insertArgs(revisionColumns, revision)...); err != nil {
+		return false, err
+	}
+	if err := tx.QueryRow(ctx, updateNoticeQuery("now()"), updateNoticeArgs(notice)...).Scan(&notice.UpdatedAt); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// GetRevisions returns the previous versions of a notice, oldest first.
+func (n *NoticeStore) GetRevisions(ctx context.Context, noticeID string) ([]*models.NoticeRevision, error) {
+	rows, err := n.pool.Query(ctx, getRevisionsQuery, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.NoticeRevision{}
+	for rows.Next() {
+		revision, err := scanRow(rows, revisionColumns)
+		if err != nil {
 			return nil, err
 		}
-		notices = append(notices, &notice)
+		revisions = append(revisions, revision)
 	}
 
-	return notices, nil
+	return revisions, rows.Err()
 }
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// NoticeRepository stores notices. A notice is only stored once per guid and
+// source, the duplicates are skipped by CreateNotices and updated by
+// UpdateNotices when they were edited.
+type NoticeRepository interface {
+	// CreateNotices returns the notices that were new. Notices that fail to
+	// insert are reported in an *InsertError, the rest are still inserted.
+	CreateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error)
+	// GetGuids returns the set of guids already stored for sourceId.
+	GetGuids(ctx context.Context, sourceId string) (map[string]bool, error)
+	// GetRecentGuids returns the set of guids stored for sourceId since.
+	GetRecentGuids(ctx context.Context, sourceId string, since time.Time) (map[string]bool, error)
+	// GetLatestNotices returns the notices stored in the last day.
+	GetLatestNotices(ctx context.Context) ([]*models.Notice, error)
+	// GetDuplicateCandidates returns the notices stored since that aren't
+	// duplicates of another one and may be the same job as a notice: the
+	// ones whose dedupe key has the same title, and the ones whose SimHash
+	// has one of the 16 bit bands of simHash. A nil simHash only looks up the
+	// title. Oldest first.
+	GetDuplicateCandidates(ctx context.Context, since time.Time, title string, simHash *int64) ([]*models.Notice, error)
+	// SetCanonical marks a notice as a duplicate of canonicalID.
+	SetCanonical(ctx context.Context, noticeID string, canonicalID string) error
+	// UpdateNotices saves the stored notices whose title or body changed,
+	// keeping their previous version as a revision, and returns them with
+	// the id they are stored under. Notices that fail to update are reported
+	// in an *InsertError.
+	UpdateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error)
+	// GetRevisions returns the previous versions of a notice, oldest first.
+	GetRevisions(ctx context.Context, noticeID string) ([]*models.NoticeRevision, error)
+}
+
+// SourceRepository stores the "Source" rows notices reference.
+type SourceRepository interface {
+	GetSourceByName(ctx context.Context, name string) (*models.Source, error)
+	// EnsureSource creates the source, leaving an existing row untouched.
+	EnsureSource(ctx context.Context, name string, description string, homepage string) error
+}
+
+// FeedStateRepository keeps the validators of every feed, so a feed that
+// hasn't changed isn't downloaded again.
+type FeedStateRepository interface {
+	// GetFeedState returns nil when nothing is stored for url.
+	GetFeedState(ctx context.Context, url string) (*models.FeedState, error)
+	// SaveFeedState replaces the stored state of the feed.
+	SaveFeedState(ctx context.Context, state *models.FeedState) error
+}
+
+// KeywordRepository stores the keywords notices are tagged with, linked to
+// their notices through "_KeywordToNotice".
+type KeywordRepository interface {
+	// UpsertKeywords creates the keywords that aren't stored yet and returns
+	// all of them, in the order of values.
+	UpsertKeywords(ctx context.Context, values []string) ([]*models.Keyword, error)
+	// LinkKeywords tags a notice with keywords, the links it already has are
+	// kept.
+	LinkKeywords(ctx context.Context, noticeID string, keywordIDs []string) error
+	// SetKeywords replaces the keywords of a notice, so an edited notice
+	// loses the ones it no longer mentions. No keywordIDs removes them all.
+	SetKeywords(ctx context.Context, noticeID string, keywordIDs []string) error
+	// GetNoticeKeywords returns the keywords of a notice, sorted by value.
+	GetNoticeKeywords(ctx context.Context, noticeID string) ([]*models.Keyword, error)
+}
+
+// Migrations applies and rolls back the schema of a backend.
+type Migrations interface {
+	Up(ctx context.Context) ([]Migration, error)
+	Down(ctx context.Context, steps int) ([]Migration, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+// Backend is a database the scraper can run on.
+type Backend struct {
+	Notices    NoticeRepository
+	Sources    SourceRepository
+	FeedStates FeedStateRepository
+	Keywords   KeywordRepository
+	Migrations Migrations
+	close      func()
+}
+
+func (b *Backend) Close() {
+	b.close()
+}
+
+// NewPostgres uses pool for storage. The schema is only changed by running
+// the migrations.
+func NewPostgres(pool *pgxpool.Pool) (*Backend, error) {
+	migrator, err := NewMigrator(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backend{
+		Notices:    InitNotice(pool),
+		Sources:    InitSource(pool),
+		FeedStates: InitFeedState(pool),
+		Keywords:   InitKeyword(pool),
+		Migrations: migrator,
+		close:      func() { CloseDB(pool) },
+	}, nil
+}
+
+// OpenSQLite opens, or creates, the SQLite database at path and applies any
+// pending migrations.
+func OpenSQLite(ctx context.Context, path string) (*Backend, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := newSQLiteMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to migrate %s: %w", path, err)
+	}
+
+	return &Backend{
+		Notices:    &sqliteNotices{db: db},
+		Sources:    &sqliteSources{db: db},
+		FeedStates: &sqliteFeedStates{db: db},
+		Keywords:   &sqliteKeywords{db: db},
+		Migrations: migrator,
+		close:      func() { db.Close() },
+	}, nil
+}
@@ -0,0 +1,493 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// testBackends returns every backend to run the repository tests against.
+// Memory and SQLite always run, Postgres only with TEST_DATABASE_URL.
+func testBackends(t *testing.T) map[string]func(t *testing.T) *Backend {
+	return map[string]func(t *testing.T) *Backend{
+		"memory": func(t *testing.T) *Backend {
+			return NewMemory()
+		},
+		"sqlite": func(t *testing.T) *Backend {
+			backend, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "scraper.db"))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			t.Cleanup(backend.Close)
+			return backend
+		},
+		"postgres": func(t *testing.T) *Backend {
+			// The pool is closed by migratedPool
+			backend, err := NewPostgres(migratedPool(t))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			return backend
+		},
+	}
+}
+
+func testNotice(sourceId string, guid string) *models.Notice {
+	return &models.Notice{
+		ID:         sourceId + "-" + guid,
+		Title:      "Engineer " + guid,
+		Body:       "Body",
+		URL:        "https://example.com/" + guid,
+		AuthorName: "Author",
+		AuthorURL:  "https://example.com",
+		SourceID:   sourceId,
+		Raw:        "{}",
+		Guid:       guid,
+	}
+}
+
+func TestRepositories(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+
+			for _, source := range []string{"First", "Second"} {
+				// Twice, the second call leaves the row alone
+				for range 2 {
+					if err := backend.Sources.EnsureSource(ctx, source, source+" jobs", "https://example.com"); err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+				}
+			}
+
+			source, err := backend.Sources.GetSourceByName(ctx, "First")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if source.Description == nil || *source.Description != "First jobs" || source.CreatedAt.IsZero() {
+				t.Errorf("Unexpected source %+v", source)
+			}
+
+			published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+			remote := true
+			company := "Acme"
+			withDetails := testNotice("First", "a")
+			withDetails.PublishedDate = &published
+			withDetails.Remote = &remote
+			withDetails.Company = &company
+			hourly, period := 62.5, "hour"
+			withDetails.SalaryMin = &hourly
+			withDetails.SalaryPeriod = &period
+			kind, regions := "remote-region", "US, EU"
+			withDetails.Workplace, withDetails.Regions = &kind, &regions
+
+			// The same guid is a different notice on another source
+			inserted, err := backend.Notices.CreateNotices(ctx, []*models.Notice{
+				withDetails,
+				testNotice("Second", "a"),
+				testNotice("First", "b"),
+			})
+			if err != nil || len(inserted) != 3 {
+				t.Fatalf("Expected 3 inserted notices, got %d: %v", len(inserted), err)
+			}
+			if inserted[0].CreatedAt.IsZero() {
+				t.Errorf("Expected createdAt to be set")
+			}
+
+			inserted, err = backend.Notices.CreateNotices(ctx, []*models.Notice{
+				testNotice("First", "a"),
+				testNotice("First", "c"),
+			})
+			if err != nil || len(inserted) != 1 || inserted[0].Guid != "c" {
+				t.Errorf("Expected only c to be inserted, got %d: %v", len(inserted), err)
+			}
+
+			guids, err := backend.Notices.GetGuids(ctx, "First")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(guids) != 3 || !guids["a"] || !guids["b"] || !guids["c"] {
+				t.Errorf("Unexpected guids %v", guids)
+			}
+			recent, err := backend.Notices.GetRecentGuids(ctx, "First", time.Now().Add(-time.Hour))
+			if err != nil || len(recent) != 3 {
+				t.Errorf("Expected 3 recent guids, got %v: %v", recent, err)
+			}
+			recent, err = backend.Notices.GetRecentGuids(ctx, "First", time.Now().Add(time.Hour))
+			if err != nil || len(recent) != 0 {
+				t.Errorf("Expected no guids stored after now, got %v: %v", recent, err)
+			}
+
+			notices, err := backend.Notices.GetLatestNotices(ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(notices) != 4 {
+				t.Fatalf("Expected 4 latest notices, got %d", len(notices))
+			}
+			var stored *models.Notice
+			for _, n := range notices {
+				if n.ID == withDetails.ID {
+					stored = n
+				}
+			}
+			if stored == nil {
+				t.Fatalf("Notice %s not returned", withDetails.ID)
+			}
+			if stored.PublishedDate == nil || !stored.PublishedDate.Equal(published) {
+				t.Errorf("Expected published date %v, got %v", published, stored.PublishedDate)
+			}
+			if stored.Remote == nil || !*stored.Remote || stored.Company == nil || *stored.Company != company {
+				t.Errorf("Unexpected details %+v", stored)
+			}
+			if stored.SalaryMin == nil || *stored.SalaryMin != hourly || stored.SalaryPeriod == nil || *stored.SalaryPeriod != period || stored.SalaryMax != nil {
+				t.Errorf("Unexpected salary %+v", stored)
+			}
+			if stored.Workplace == nil || *stored.Workplace != kind || stored.Regions == nil || *stored.Regions != regions || stored.Places != nil {
+				t.Errorf("Unexpected workplace %+v", stored)
+			}
+		})
+	}
+}
+
+func TestRepositoriesInsertError(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			if err := backend.Sources.EnsureSource(ctx, "First", "", ""); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			inserted, err := backend.Notices.CreateNotices(ctx, []*models.Notice{
+				testNotice("First", "a"),
+				testNotice("Nowhere", "b"),
+				testNotice("First", "c"),
+			})
+			if len(inserted) != 2 {
+				t.Errorf("Expected the 2 valid notices to be inserted, got %d", len(inserted))
+			}
+
+			var insertErr *InsertError
+			if !errors.As(err, &insertErr) {
+				t.Fatalf("Expected an *InsertError, got %v", err)
+			}
+			if len(insertErr.Rows) != 1 || !strings.Contains(insertErr.Rows[0].Reason(), `source "Nowhere" doesn't exist`) {
+				t.Errorf("Unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestFeedStates(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			url := "https://example.com/feed.rss"
+
+			state, err := backend.FeedStates.GetFeedState(ctx, url)
+			if err != nil || state != nil {
+				t.Fatalf("Expected no state for a new feed, got %+v: %v", state, err)
+			}
+
+			etag, lastModified := `"v1"`, "Mon, 03 Feb 2025 14:21:07 GMT"
+			if err := backend.FeedStates.SaveFeedState(ctx, &models.FeedState{URL: url, ETag: &etag, LastModified: &lastModified}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// Saving again replaces the state, a validator the feed stopped
+			// sending is cleared
+			etag = `"v2"`
+			if err := backend.FeedStates.SaveFeedState(ctx, &models.FeedState{URL: url, ETag: &etag}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			state, err = backend.FeedStates.GetFeedState(ctx, url)
+			if err != nil || state == nil {
+				t.Fatalf("Expected a stored state, got %+v: %v", state, err)
+			}
+			if state.ETag == nil || *state.ETag != `"v2"` || state.LastModified != nil {
+				t.Errorf("Unexpected state %+v", state)
+			}
+			if time.Since(state.UpdatedAt) > time.Hour {
+				t.Errorf("Expected updatedAt to be set, got %v", state.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			if err := backend.Sources.EnsureSource(ctx, "First", "", ""); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			notice := testNotice("First", "1")
+			if _, err := backend.Notices.CreateNotices(ctx, []*models.Notice{notice}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			keywords, err := backend.Keywords.UpsertKeywords(ctx, []string{"Kubernetes", "Go"})
+			if err != nil || len(keywords) != 2 || keywords[0].Value != "Kubernetes" || keywords[1].Value != "Go" {
+				t.Fatalf("Expected the keywords in order, got %v: %v", keywords, err)
+			}
+
+			// Upserting again returns the stored rows
+			again, err := backend.Keywords.UpsertKeywords(ctx, []string{"Go", "Rust"})
+			if err != nil || len(again) != 2 {
+				t.Fatalf("Expected 2 keywords, got %v: %v", again, err)
+			}
+			if again[0].ID != keywords[1].ID {
+				t.Errorf("Expected the id of the stored keyword, got %s and %s", again[0].ID, keywords[1].ID)
+			}
+			if time.Since(again[1].UpdatedAt) > time.Hour {
+				t.Errorf("Expected updatedAt to be set, got %v", again[1].UpdatedAt)
+			}
+
+			// Linking twice keeps a single link
+			for i := 0; i < 2; i++ {
+				err = backend.Keywords.LinkKeywords(ctx, notice.ID, []string{keywords[0].ID, keywords[1].ID})
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			linked, err := backend.Keywords.GetNoticeKeywords(ctx, notice.ID)
+			if err != nil || len(linked) != 2 || linked[0].Value != "Go" || linked[1].Value != "Kubernetes" {
+				t.Errorf("Expected Go and Kubernetes, got %v: %v", linked, err)
+			}
+
+			if err := backend.Keywords.LinkKeywords(ctx, "missing", []string{keywords[0].ID}); err == nil {
+				t.Errorf("Expected linking a missing notice to fail")
+			}
+
+			// Setting replaces the links, setting none removes them
+			if err := backend.Keywords.SetKeywords(ctx, notice.ID, []string{keywords[1].ID}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			linked, err = backend.Keywords.GetNoticeKeywords(ctx, notice.ID)
+			if err != nil || len(linked) != 1 || linked[0].Value != "Go" {
+				t.Errorf("Expected only Go, got %v: %v", linked, err)
+			}
+			if err := backend.Keywords.SetKeywords(ctx, notice.ID, nil); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			linked, err = backend.Keywords.GetNoticeKeywords(ctx, notice.ID)
+			if err != nil || len(linked) != 0 {
+				t.Errorf("Expected no keywords, got %v: %v", linked, err)
+			}
+		})
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			if err := backend.Sources.EnsureSource(ctx, "First", "", ""); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			first, second, third := testNotice("First", "1"), testNotice("First", "2"), testNotice("First", "3")
+			key, hash := "engineer|acme", int64(-42)
+			first.DedupeKey, first.SimHash = &key, &hash
+			// Shares the title but not a band of the SimHash
+			thirdKey, thirdHash := "engineer|", ^hash
+			third.DedupeKey, third.SimHash = &thirdKey, &thirdHash
+			if _, err := backend.Notices.CreateNotices(ctx, []*models.Notice{first, second, third}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if err := backend.Notices.SetCanonical(ctx, second.ID, first.ID); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := backend.Notices.SetCanonical(ctx, third.ID, "First-missing"); err == nil {
+				t.Errorf("Expected an error for a missing canonical notice")
+			}
+			if err := backend.Notices.SetCanonical(ctx, third.ID, third.ID); err == nil {
+				t.Errorf("Expected an error for a notice that is its own duplicate")
+			}
+
+			since := time.Now().Add(-time.Hour)
+			candidates := func(title string, simHash *int64) []string {
+				t.Helper()
+				notices, err := backend.Notices.GetDuplicateCandidates(ctx, since, title, simHash)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				var ids []string
+				for _, n := range notices {
+					ids = append(ids, n.ID)
+				}
+				return ids
+			}
+
+			// The duplicate isn't a candidate, the others share the title
+			if ids := candidates("engineer", nil); !slices.Equal(ids, []string{first.ID, third.ID}) {
+				t.Errorf("Expected the first and third notices, got %v", ids)
+			}
+			if ids := candidates("engineer lead", nil); len(ids) != 0 {
+				t.Errorf("Expected no notices for a longer title, got %v", ids)
+			}
+			// A SimHash 3 bits away still has the top band in common
+			near := hash ^ (1 | 1<<20 | 1<<40)
+			if ids := candidates("designer", &near); !slices.Equal(ids, []string{first.ID}) {
+				t.Errorf("Expected the notice with a band in common, got %v", ids)
+			}
+			if ids := candidates("", &near); !slices.Equal(ids, []string{first.ID}) {
+				t.Errorf("Expected the notice with a band in common without a title, got %v", ids)
+			}
+			far := hash ^ (1 | 1<<20 | 1<<40 | 1<<60)
+			if ids := candidates("designer", &far); len(ids) != 0 {
+				t.Errorf("Expected no notices without a band in common, got %v", ids)
+			}
+
+			notices, err := backend.Notices.GetDuplicateCandidates(ctx, since, "engineer", nil)
+			if err != nil || len(notices) == 0 || notices[0].DedupeKey == nil || *notices[0].DedupeKey != key || notices[0].SimHash == nil || *notices[0].SimHash != hash {
+				t.Errorf("Expected the fingerprint to be stored, got %+v: %v", notices, err)
+			}
+
+			notices, err = backend.Notices.GetDuplicateCandidates(ctx, time.Now().Add(time.Hour), "engineer", &hash)
+			if err != nil || len(notices) != 0 {
+				t.Errorf("Expected no notices stored after now, got %d: %v", len(notices), err)
+			}
+
+			notices, err = backend.Notices.GetLatestNotices(ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, n := range notices {
+				if n.ID == second.ID && (n.CanonicalID == nil || *n.CanonicalID != first.ID) {
+					t.Errorf("Expected the duplicate to point at %s, got %v", first.ID, n.CanonicalID)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateNotices(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			if err := backend.Sources.EnsureSource(ctx, "First", "", ""); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := backend.Notices.CreateNotices(ctx, []*models.Notice{testNotice("First", "1"), testNotice("First", "2")}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// Found again under a new id, as sources make one for every item
+			edited := testNotice("First", "1")
+			edited.ID = "new-id"
+			edited.Body = "Edited body"
+			edited.Raw = `{"edited":true}`
+			same := testNotice("First", "2")
+			same.ID = "other-id"
+			same.Raw = `{"score":2}`
+			missing := testNotice("First", "3")
+
+			updated, err := backend.Notices.UpdateNotices(ctx, []*models.Notice{edited, same, missing})
+			if err != nil || len(updated) != 1 || updated[0] != edited {
+				t.Fatalf("Expected only the edited notice to be updated, got %v: %v", updated, err)
+			}
+			if edited.ID != "First-1" || edited.UpdatedAt == nil || edited.CreatedAt.IsZero() {
+				t.Errorf("Expected the id and times of the stored notice, got %s %v %v", edited.ID, edited.CreatedAt, edited.UpdatedAt)
+			}
+
+			notices, err := backend.Notices.GetLatestNotices(ctx)
+			if err != nil || len(notices) != 2 {
+				t.Fatalf("Expected 2 notices, got %d: %v", len(notices), err)
+			}
+			for _, n := range notices {
+				if n.ID == "First-1" && (n.Body != "Edited body" || n.Raw != `{"edited":true}` || n.UpdatedAt == nil || n.ContentHash == nil || *n.ContentHash != *edited.ContentHash) {
+					t.Errorf("Expected the edit to be stored, got %+v", n)
+				}
+				if n.ID == "First-2" && n.Raw != "{}" {
+					t.Errorf("Expected the unchanged notice to be left alone, got %+v", n)
+				}
+			}
+
+			revisions, err := backend.Notices.GetRevisions(ctx, "First-1")
+			if err != nil || len(revisions) != 1 {
+				t.Fatalf("Expected 1 revision, got %d: %v", len(revisions), err)
+			}
+			if r := revisions[0]; r.Body != "Body" || r.Raw != "{}" || r.ContentHash == nil || *r.ContentHash == *edited.ContentHash || r.CreatedAt.IsZero() {
+				t.Errorf("Expected the previous version, got %+v", r)
+			}
+
+			// Nothing changed since
+			again := testNotice("First", "1")
+			again.Body = "Edited body"
+			updated, err = backend.Notices.UpdateNotices(ctx, []*models.Notice{again})
+			if err != nil || len(updated) != 0 {
+				t.Errorf("Expected nothing to be updated, got %d: %v", len(updated), err)
+			}
+		})
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			if err := backend.Sources.EnsureSource(ctx, "First", "", ""); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+			if _, err := backend.Notices.GetGuids(ctx, "First"); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+
+			// Not retried row by row, every row would fail the same way
+			_, err := backend.Notices.CreateNotices(ctx, []*models.Notice{testNotice("First", "a")})
+			var insertErr *InsertError
+			if !errors.Is(err, context.Canceled) || errors.As(err, &insertErr) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	backend, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "scraper.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer backend.Close()
+
+	statuses, err := backend.Migrations.Status(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("Expected migration %d to be applied on open", s.Version)
+		}
+	}
+
+	// The first migration can't be rolled back
+	rolledBack, err := backend.Migrations.Down(ctx, len(statuses))
+	if !errors.Is(err, ErrIrreversible) || len(rolledBack) != len(statuses)-1 {
+		t.Fatalf("Expected every migration but the first to be rolled back, got %d: %v", len(rolledBack), err)
+	}
+
+	applied, err := backend.Migrations.Up(ctx)
+	if err != nil || len(applied) != len(statuses)-1 {
+		t.Errorf("Expected the rolled back migrations to be applied again, got %d: %v", len(applied), err)
+	}
+}
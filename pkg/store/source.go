@@ -2,29 +2,32 @@ package store
 
 import (
 	"context"
+	"fmt"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
 )
 
 type Source struct {
-	conn *pgx.Conn
+	pool *pgxpool.Pool
 }
 
-func InitSource(conn *pgx.Conn) *Source {
-	return &Source{conn}
+func InitSource(pool *pgxpool.Pool) *Source {
+	return &Source{pool}
 }
 
-func (s *Source) GetSourceByName(name string) (*models.Source, error) {
-	var source *models.Source = &models.Source{}
-	err := s.conn.QueryRow(context.Background(), `SELECT id, name FROM "Source" WHERE "name" = $1`, name).Scan(
-		&source.ID,
-		&source.Name,
-	)
+func (s *Source) GetSourceByName(ctx context.Context, name string) (*models.Source, error) {
+	row := s.pool.QueryRow(ctx, fmt.Sprintf(`SELECT %s FROM "Source" WHERE "name" = $1`, selectList(sourceColumns)), name)
+	return scanRow(row, sourceColumns)
+}
 
-	if err != nil {
-		return nil, err
-	}
+// EnsureSource creates the source row notices reference through "sourceId",
+// leaving an existing row untouched.
+func (s *Source) EnsureSource(ctx context.Context, name string, description string, homepage string) error {
+	_, err := s.pool.Exec(ctx, `
+	INSERT INTO "Source" (name, description, homepage, "updatedAt")
+	VALUES ($1, $2, $3, now())
+	ON CONFLICT (name) DO NOTHING`, name, description, homepage)
 
-	return source, nil
+	return err
 }
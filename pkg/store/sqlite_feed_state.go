@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// sqliteFeedStates is the FeedStateRepository of OpenSQLite.
+type sqliteFeedStates struct {
+	db *sql.DB
+}
+
+func (f *sqliteFeedStates) GetFeedState(ctx context.Context, url string) (*models.FeedState, error) {
+	state, err := scanRow(f.db.QueryRowContext(ctx, getFeedStateQuery, url), feedStateColumns)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return state, err
+}
+
+func (f *sqliteFeedStates) SaveFeedState(ctx context.Context, state *models.FeedState) error {
+	_, err := f.db.ExecContext(ctx, saveFeedStateQuery(`strftime('%Y-%m-%d %H:%M:%f', 'now')`), insertArgs(feedStateColumns, state)...)
+	return err
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// sqliteKeywords is the KeywordRepository of OpenSQLite.
+type sqliteKeywords struct {
+	db *sql.DB
+}
+
+func (k *sqliteKeywords) UpsertKeywords(ctx context.Context, values []string) ([]*models.Keyword, error) {
+	query := upsertKeywordQuery(`strftime('%Y-%m-%d %H:%M:%f', 'now')`)
+
+	keywords := make([]*models.Keyword, len(values))
+	for i, value := range values {
+		keyword, err := scanRow(k.db.QueryRowContext(ctx, query, insertArgs(keywordColumns, newKeyword(value))...), keywordColumns)
+		if err != nil {
+			return nil, fmt.Errorf("unable to upsert keyword %q: %w", value, err)
+		}
+		keywords[i] = keyword
+	}
+	return keywords, nil
+}
+
+func (k *sqliteKeywords) LinkKeywords(ctx context.Context, noticeID string, keywordIDs []string) error {
+	for _, id := range keywordIDs {
+		if _, err := k.db.ExecContext(ctx, linkKeywordQuery, id, noticeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *sqliteKeywords) SetKeywords(ctx context.Context, noticeID string, keywordIDs []string) error {
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, unlinkKeywordsQuery, noticeID); err != nil {
+		return err
+	}
+	for _, id := range keywordIDs {
+		if _, err := tx.ExecContext(ctx, linkKeywordQuery, id, noticeID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (k *sqliteKeywords) GetNoticeKeywords(ctx context.Context, noticeID string) ([]*models.Keyword, error) {
+	rows, err := k.db.QueryContext(ctx, getNoticeKeywordsQuery, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keywords []*models.Keyword
+	for rows.Next() {
+		keyword, err := scanRow(rows, keywordColumns)
+		if err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, keyword)
+	}
+
+	return keywords, rows.Err()
+}
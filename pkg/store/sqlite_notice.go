@@ -0,0 +1,292 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	errorHandler "github.com/justinemmanuelmercado/go-scraper/pkg"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// sqliteNotices is the NoticeRepository of OpenSQLite. It runs the same
+// queries as NoticeStore wherever the SQL is the same.
+type sqliteNotices struct {
+	db *sql.DB
+}
+
+func (n *sqliteNotices) CreateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	inserted, err := n.insertTx(ctx, notices)
+	if err == nil {
+		return inserted, nil
+	}
+	if !isRowError(err) {
+		return nil, err
+	}
+
+	// Like the Postgres batch, one failing row rolls back the transaction
+	errorHandler.HandleErrorWithSection(err, "Batch insert failed, inserting notices one at a time", "Database")
+	return n.insertEach(ctx, notices)
+}
+
+func (n *sqliteNotices) insertTx(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	tx, err := n.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var inserted []*models.Notice
+	for _, notice := range notices {
+		isNew, err := scanInsertedRow(tx.QueryRowContext(ctx, insertNoticeQuery, noticeArgs(notice)...), notice)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			inserted = append(inserted, notice)
+		}
+	}
+
+	return inserted, tx.Commit()
+}
+
+func (n *sqliteNotices) insertEach(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	var inserted []*models.Notice
+	var failed []*RowError
+
+	for _, notice := range notices {
+		row := n.db.QueryRowContext(ctx, insertNoticeQuery, noticeArgs(notice)...)
+		isNew, err := scanInsertedRow(row, notice)
+		if err != nil {
+			failed = append(failed, &RowError{Notice: notice, Err: err})
+			continue
+		}
+		if isNew {
+			inserted = append(inserted, notice)
+		}
+	}
+
+	if len(failed) > 0 {
+		return inserted, &InsertError{Rows: failed, Attempted: len(notices)}
+	}
+	return inserted, nil
+}
+
+// scanInsertedRow is scanInserted for database/sql, where a conflict returns
+// sql.ErrNoRows.
+func scanInsertedRow(row *sql.Row, notice *models.Notice) (bool, error) {
+	var createdAt time.Time
+	err := row.Scan(&createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	notice.CreatedAt = createdAt
+	return true, nil
+}
+
+func (n *sqliteNotices) GetGuids(ctx context.Context, sourceId string) (map[string]bool, error) {
+	rows, err := n.db.QueryContext(ctx, fmt.Sprintf(`SELECT guid FROM "%s" WHERE "sourceId" = $1 AND guid IS NOT NULL`, tableName), sourceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guids := map[string]bool{}
+	for rows.Next() {
+		var guid string
+		if err := rows.Scan(&guid); err != nil {
+			return nil, err
+		}
+		guids[guid] = true
+	}
+
+	return guids, rows.Err()
+}
+
+func (n *sqliteNotices) GetRecentGuids(ctx context.Context, sourceId string, since time.Time) (map[string]bool, error) {
+	rows, err := n.db.QueryContext(ctx, fmt.Sprintf(`SELECT guid FROM "%s" WHERE "sourceId" = $1 AND guid IS NOT NULL AND "createdAt" >= strftime('%%Y-%%m-%%d %%H:%%M:%%f', $2)`, tableName), sourceId, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guids := map[string]bool{}
+	for rows.Next() {
+		var guid string
+		if err := rows.Scan(&guid); err != nil {
+			return nil, err
+		}
+		guids[guid] = true
+	}
+
+	return guids, rows.Err()
+}
+
+func (n *sqliteNotices) GetLatestNotices(ctx context.Context) ([]*models.Notice, error) {
+	rows, err := n.db.QueryContext(ctx, fmt.Sprintf(`
+	SELECT %s FROM "%s"
+	WHERE "createdAt" >= strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now', '-1 day')
+	AND "sourceId" != 'Reddit'
+	ORDER BY "publishedDate" DESC
+	`, selectList(noticeColumns), tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notices := []*models.Notice{}
+	for rows.Next() {
+		notice, err := scanRow(rows, noticeColumns)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, notice)
+	}
+
+	return notices, rows.Err()
+}
+
+func (n *sqliteNotices) GetDuplicateCandidates(ctx context.Context, since time.Time, title string, simHash *int64) ([]*models.Notice, error) {
+	// Text compares in byte order in SQLite, so the index of the dedupe key
+	// is used without a collation. The unary + keeps the planner off the
+	// indexes of createdAt and canonicalId, which match most of the table,
+	// so it looks up the key and the bands
+	args := append([]any{since.UTC()}, duplicateCandidatesArgs(title, simHash)...)
+	rows, err := n.db.QueryContext(ctx, fmt.Sprintf(`
+	SELECT %s FROM "%s"
+	WHERE +"createdAt" >= strftime('%%Y-%%m-%%d %%H:%%M:%%f', $1) AND +"canonicalId" IS NULL AND (
+		("dedupeKey" >= $2 AND "dedupeKey" < $3)
+		OR ("simhash" & 65535) = $4
+		OR (("simhash" >> 16) & 65535) = $5
+		OR (("simhash" >> 32) & 65535) = $6
+		OR (("simhash" >> 48) & 65535) = $7
+	)
+	ORDER BY "createdAt", id
+	`, selectList(noticeColumns), tableName), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notices := []*models.Notice{}
+	for rows.Next() {
+		notice, err := scanRow(rows, noticeColumns)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, notice)
+	}
+
+	return notices, rows.Err()
+}
+
+func (n *sqliteNotices) SetCanonical(ctx context.Context, noticeID string, canonicalID string) error {
+	result, err := n.db.ExecContext(ctx, setCanonicalQuery, noticeID, canonicalID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return fmt.Errorf("notice %q or %q doesn't exist", noticeID, canonicalID)
+	}
+	return nil
+}
+
+func (n *sqliteNotices) UpdateNotices(ctx context.Context, notices []*models.Notice) ([]*models.Notice, error) {
+	// SQLite has no arrays, the guids and sources are passed as JSON pairs
+	keys := make([][2]string, len(notices))
+	for i, notice := range notices {
+		keys[i] = [2]string{notice.Guid, notice.SourceID}
+	}
+	encoded, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := n.db.QueryContext(ctx, fmt.Sprintf(`
+	SELECT "sourceId", guid, "contentHash" FROM "%s"
+	WHERE (guid, "sourceId") IN (SELECT json_extract(value, '$[0]'), json_extract(value, '$[1]') FROM json_each($1))`, tableName), string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := scanHashes(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []*models.Notice
+	var failed []*RowError
+
+	for _, notice := range changedNotices(notices, hashes) {
+		changed, err := n.update(ctx, notice)
+		if err != nil {
+			failed = append(failed, &RowError{Notice: notice, Err: err})
+			continue
+		}
+		if changed {
+			updated = append(updated, notice)
+		}
+	}
+
+	if len(failed) > 0 {
+		return updated, &InsertError{Rows: failed, Attempted: len(notices), Update: true}
+	}
+	return updated, nil
+}
+
+// update is NoticeStore.update without the lock, the single connection
+// already runs one transaction at a time.
+func (n *sqliteNotices) update(ctx context.Context, notice *models.Notice) (bool, error) {
+	tx, err := n.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	stored, err := scanRow(tx.QueryRowContext(ctx, storedNoticeQuery, notice.Guid, notice.SourceID), noticeColumns)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	revision := revise(stored, notice)
+	if revision == nil {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, insertRevisionQuery, insertArgs(revisionColumns, revision)...); err != nil {
+		return false, err
+	}
+	query := updateNoticeQuery(`strftime('%Y-%m-%d %H:%M:%f', 'now')`)
+	if err := tx.QueryRowContext(ctx, query, updateNoticeArgs(notice)...).Scan(&notice.UpdatedAt); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (n *sqliteNotices) GetRevisions(ctx context.Context, noticeID string) ([]*models.NoticeRevision, error) {
+	rows, err := n.db.QueryContext(ctx, getRevisionsQuery, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.NoticeRevision{}
+	for rows.Next() {
+		revision, err := scanRow(rows, revisionColumns)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
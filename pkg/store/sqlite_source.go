@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// sqliteSources is the SourceRepository of OpenSQLite.
+type sqliteSources struct {
+	db *sql.DB
+}
+
+func (s *sqliteSources) GetSourceByName(ctx context.Context, name string) (*models.Source, error) {
+	row := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s FROM "Source" WHERE "name" = $1`, selectList(sourceColumns)), name)
+	return scanRow(row, sourceColumns)
+}
+
+func (s *sqliteSources) EnsureSource(ctx context.Context, name string, description string, homepage string) error {
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO "Source" (name, description, homepage, "updatedAt")
+	VALUES ($1, $2, $3, strftime('%Y-%m-%d %H:%M:%f', 'now'))
+	ON CONFLICT (name) DO NOTHING`, name, description, homepage)
+
+	return err
+}
@@ -0,0 +1,115 @@
+package workplace
+
+import (
+	"github.com/justinemmanuelmercado/go-scraper/pkg/config"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/keywords"
+)
+
+// places are the countries and cities picked out of notices. Names that are
+// also words, like Turkey or Nice, match with their case only, and ones that
+// are too ambiguous, like Georgia, are left out.
+var places = keywords.New([]config.Keyword{
+	// Countries
+	{Name: "United States", Aliases: []string{"USA"}},
+	{Name: "Canada"},
+	{Name: "Mexico"},
+	{Name: "Brazil"},
+	{Name: "Argentina"},
+	{Name: "Colombia"},
+	{Name: "Chile", CaseSensitive: true},
+	{Name: "Peru"},
+	{Name: "United Kingdom", Aliases: []string{"UK", "Britain"}},
+	{Name: "Ireland"},
+	{Name: "Germany"},
+	{Name: "France"},
+	{Name: "Spain"},
+	{Name: "Portugal"},
+	{Name: "Italy"},
+	{Name: "Netherlands", Aliases: []string{"Holland"}},
+	{Name: "Belgium"},
+	{Name: "Switzerland"},
+	{Name: "Austria"},
+	{Name: "Poland"},
+	{Name: "Czech Republic", Aliases: []string{"Czechia"}},
+	{Name: "Sweden"},
+	{Name: "Norway"},
+	{Name: "Denmark"},
+	{Name: "Finland"},
+	{Name: "Estonia"},
+	{Name: "Romania"},
+	{Name: "Ukraine"},
+	{Name: "Greece"},
+	{Name: "Turkey", CaseSensitive: true},
+	{Name: "Israel"},
+	{Name: "India"},
+	{Name: "Pakistan"},
+	{Name: "Singapore"},
+	{Name: "Japan"},
+	{Name: "South Korea"},
+	{Name: "China"},
+	{Name: "Taiwan"},
+	{Name: "Vietnam"},
+	{Name: "Philippines"},
+	{Name: "Indonesia"},
+	{Name: "Malaysia"},
+	{Name: "Thailand"},
+	{Name: "Australia"},
+	{Name: "New Zealand"},
+	{Name: "South Africa"},
+	{Name: "Nigeria"},
+	{Name: "Kenya"},
+	{Name: "Egypt"},
+	{Name: "United Arab Emirates", Aliases: []string{"UAE"}},
+
+	// Cities
+	{Name: "New York", Aliases: []string{"NYC"}},
+	{Name: "San Francisco", Aliases: []string{"SF", "Bay Area"}},
+	{Name: "Los Angeles"},
+	{Name: "Seattle"},
+	{Name: "Boston"},
+	{Name: "Austin", CaseSensitive: true},
+	{Name: "Chicago"},
+	{Name: "Denver"},
+	{Name: "Atlanta"},
+	{Name: "Miami"},
+	{Name: "Washington, D.C.", Aliases: []string{"Washington DC"}},
+	{Name: "Palo Alto"},
+	{Name: "Mountain View"},
+	{Name: "Toronto"},
+	{Name: "Vancouver"},
+	{Name: "Montreal"},
+	{Name: "Mexico City"},
+	{Name: "São Paulo", Aliases: []string{"Sao Paulo"}},
+	{Name: "Buenos Aires"},
+	{Name: "London"},
+	{Name: "Manchester"},
+	{Name: "Edinburgh"},
+	{Name: "Dublin"},
+	{Name: "Paris"},
+	{Name: "Berlin"},
+	{Name: "Munich"},
+	{Name: "Hamburg"},
+	{Name: "Amsterdam"},
+	{Name: "Barcelona"},
+	{Name: "Madrid"},
+	{Name: "Lisbon"},
+	{Name: "Zurich"},
+	{Name: "Vienna"},
+	{Name: "Prague"},
+	{Name: "Warsaw"},
+	{Name: "Stockholm"},
+	{Name: "Copenhagen"},
+	{Name: "Oslo"},
+	{Name: "Helsinki"},
+	{Name: "Tel Aviv"},
+	{Name: "Dubai"},
+	{Name: "Bangalore", Aliases: []string{"Bengaluru"}},
+	{Name: "Tokyo"},
+	{Name: "Seoul"},
+	{Name: "Hong Kong"},
+	{Name: "Sydney"},
+	{Name: "Melbourne"},
+	{Name: "Lagos"},
+	{Name: "Nairobi"},
+	{Name: "Cape Town"},
+})
@@ -0,0 +1,237 @@
+// Package workplace classifies where the work of a notice happens: remote
+// from anywhere, remote within a region or timezone band, hybrid or on-site,
+// along with the countries and cities it mentions.
+package workplace
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/keywords"
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+// Kind is how a notice lets people work.
+type Kind string
+
+const (
+	// RemoteGlobal is remote without a region or timezone it is limited to
+	RemoteGlobal Kind = "remote-global"
+	// RemoteRegion is remote from within some regions or timezones only
+	RemoteRegion Kind = "remote-region"
+	Hybrid       Kind = "hybrid"
+	Onsite       Kind = "onsite"
+)
+
+// Workplace is what a notice says about where to work from.
+type Workplace struct {
+	// Kind is empty when the notice doesn't say
+	Kind Kind
+	// Regions are the regions and timezones a remote notice is limited to,
+	// e.g. US, EU or UTC-5 to UTC+1
+	Regions []string
+	// Places are the countries and cities the notice mentions
+	Places []string
+}
+
+// IsRemote is true for both kinds of remote.
+func (w *Workplace) IsRemote() bool {
+	return w.Kind == RemoteGlobal || w.Kind == RemoteRegion
+}
+
+// regionPattern matches the name of any region, US only in capitals since
+// "us" is a word.
+const regionPattern = `(?:\bUSA?\b|\bU\.S\.(?:A\.)?|(?i:\bunited states\b|\bnorth america\b|\bamericas\b|\bcanada\b|\blatam\b|\blatin america\b|\bsouth america\b|\beurope\b|\beu\b|\bemea\b|\buk\b|\bunited kingdom\b|\bapac\b|\basia\b|\baustralia\b|\bnew zealand\b|\bindia\b|\bafrica\b))`
+
+// regionList matches a list of regions, like "the EU or the UK".
+const regionList = regionPattern + `(?:\s*(?:,|/|&|(?i:or|and))\s*(?i:the\s+)?` + regionPattern + `)*`
+
+var (
+	remoteRe    = regexp.MustCompile(`(?i)\bremote(ly)?\b|\bwfh\b|\bwork from (home|anywhere)\b|\bdistributed team\b`)
+	notRemoteRe = regexp.MustCompile(`(?i)\b(no|not|non)[- ]remote\b|\bremote (work )?(is )?not (possible|available|an option)\b`)
+	onsiteRe    = regexp.MustCompile(`(?i)\bon-?site\b|\bin[- ](the[- ])?office\b|\bin[- ]person\b|\boffice[- ]based\b|\brelocat(e|ion)\b`)
+	// hybridRe is only trusted in the location and title, "hybrid" in a
+	// body is as likely to be about hybrid apps
+	hybridRe     = regexp.MustCompile(`(?i)\bhybrid\b`)
+	hybridBodyRe = regexp.MustCompile(`(?i)\bhybrid[- ](work|working|role|position|model|schedule|setup|remote|office|arrangement)\b|\b(remote|on-?site|office)\s*(/|or|and|,)\s*hybrid\b|\b\d days? (a|per) week in (the )?office\b`)
+
+	// restrictionRes find a region a remote notice is limited to, like
+	// "Remote (US)", "EU only" or "anywhere in the US"
+	restrictionRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i:\bremote\b)[\s(,:–-]{1,4}` + regionList),
+		regexp.MustCompile(regionList + `(?i:[\s-]*(only|based|residents?|citizens?|remote|time\s?zones?|hours)\b)`),
+		regexp.MustCompile(`(?i:\b(in|within|from|across|anywhere in|located in|based in|reside in|residing in)\s+(the\s+)?)` + regionList),
+	}
+
+	// timezoneRe matches an offset or a band of them, like UTC-5 to UTC+1,
+	// or the name of a zone
+	timezoneRe = regexp.MustCompile(`(?i:\b((?:utc|gmt)\s?[+±−-]\s?\d{1,2}(?::?\d{2})?)(?:\s*(?:to|-|–)\s*((?:(?:utc|gmt)\s?)?[+±−-]\s?\d{1,2}(?::?\d{2})?))?)|\b(?:EST|EDT|PST|PDT|CST|CDT|CET|CEST|BST|AEST)\b`)
+
+	regionNames = []struct {
+		name string
+		re   *regexp.Regexp
+	}{
+		{"US", regexp.MustCompile(`\bUSA?\b|\bU\.S\.|(?i:\bunited states\b)`)},
+		{"North America", regexp.MustCompile(`(?i)\bnorth america\b`)},
+		{"Americas", regexp.MustCompile(`(?i)\bamericas\b`)},
+		{"Canada", regexp.MustCompile(`(?i)\bcanada\b`)},
+		{"LATAM", regexp.MustCompile(`(?i)\blatam\b|\blatin america\b|\bsouth america\b`)},
+		{"EU", regexp.MustCompile(`(?i)\beurope\b|\beu\b`)},
+		{"UK", regexp.MustCompile(`(?i)\buk\b|\bunited kingdom\b`)},
+		{"EMEA", regexp.MustCompile(`(?i)\bemea\b`)},
+		{"APAC", regexp.MustCompile(`(?i)\bapac\b|\basia\b`)},
+		{"Australia", regexp.MustCompile(`(?i)\baustralia\b`)},
+		{"New Zealand", regexp.MustCompile(`(?i)\bnew zealand\b`)},
+		{"India", regexp.MustCompile(`(?i)\bindia\b`)},
+		{"Africa", regexp.MustCompile(`(?i)\bafrica\b`)},
+	}
+)
+
+// Classify reads the workplace of notice out of its location, title and
+// body. The location and title are trusted over the body.
+func Classify(notice *models.Notice) *Workplace {
+	header := notice.Title
+	if notice.Location != nil {
+		header = *notice.Location + "\n" + header
+	}
+	body := keywords.PlainText(notice.Body)
+	text := header + "\n" + body
+
+	w := &Workplace{Places: places.MatchText(text)}
+
+	w.Kind = kind(header, hybridRe)
+	if w.Kind == "" {
+		w.Kind = kind(body, hybridBodyRe)
+	}
+	if w.IsRemote() {
+		w.Regions = regions(text)
+		if len(w.Regions) > 0 {
+			w.Kind = RemoteRegion
+		}
+	}
+
+	return w
+}
+
+func kind(text string, hybrid *regexp.Regexp) Kind {
+	switch {
+	case hybrid.MatchString(text):
+		return Hybrid
+	case notRemoteRe.MatchString(text):
+		return Onsite
+	case remoteRe.MatchString(text):
+		return RemoteGlobal
+	case onsiteRe.MatchString(text):
+		return Onsite
+	}
+	return ""
+}
+
+// regions returns the regions and timezones text limits the work to.
+func regions(text string) []string {
+	var found []string
+	add := func(name string) {
+		if !slices.Contains(found, name) {
+			found = append(found, name)
+		}
+	}
+
+	for _, re := range restrictionRes {
+		for _, match := range re.FindAllString(text, -1) {
+			for _, region := range regionNames {
+				if region.re.MatchString(match) {
+					add(region.name)
+				}
+			}
+		}
+	}
+	for _, match := range timezoneRe.FindAllStringSubmatch(text, -1) {
+		if match[1] == "" {
+			add(match[0])
+			continue
+		}
+
+		from := offset(match[1])
+		if match[2] == "" {
+			add(from)
+			continue
+		}
+		to := offset(match[2])
+		if to[0] == '+' || to[0] == '-' {
+			to = from[:3] + to
+		}
+		add(from + " to " + to)
+	}
+
+	return found
+}
+
+// offset normalises a timezone offset, e.g. "utc +5" to "UTC+5".
+func offset(value string) string {
+	value = strings.ToUpper(strings.Join(strings.Fields(value), ""))
+	return strings.NewReplacer("−", "-", "±", "+").Replace(value)
+}
+
+// Apply stores w on notice. The remote flag a source already set is kept.
+func (w *Workplace) Apply(notice *models.Notice) {
+	if w.Kind != "" {
+		kind := string(w.Kind)
+		notice.Workplace = &kind
+		if notice.Remote == nil {
+			remote := w.IsRemote()
+			notice.Remote = &remote
+		}
+	}
+	notice.Regions = joined(w.Regions)
+	notice.Places = joined(w.Places)
+}
+
+func joined(values []string) *string {
+	if len(values) == 0 {
+		return nil
+	}
+	value := strings.Join(values, ", ")
+	return &value
+}
+
+// FromNotice reads back the workplace stored on notice, false when it has
+// none.
+func FromNotice(notice *models.Notice) (*Workplace, bool) {
+	if notice.Workplace == nil {
+		return nil, false
+	}
+	return &Workplace{
+		Kind:    Kind(*notice.Workplace),
+		Regions: split(notice.Regions),
+		Places:  split(notice.Places),
+	}, true
+}
+
+func split(value *string) []string {
+	if value == nil {
+		return nil
+	}
+	return strings.Split(*value, ", ")
+}
+
+var kindNames = map[Kind]string{
+	RemoteGlobal: "Remote",
+	RemoteRegion: "Remote",
+	Hybrid:       "Hybrid",
+	Onsite:       "On-site",
+}
+
+// String formats w for people, e.g. "Remote (US, EU)" or "Hybrid (Berlin)".
+// Places are left out of remote workplaces, where they are usually just
+// where the company is.
+func (w *Workplace) String() string {
+	s := kindNames[w.Kind]
+	switch {
+	case len(w.Regions) > 0:
+		s += " (" + strings.Join(w.Regions, ", ") + ")"
+	case !w.IsRemote() && len(w.Places) > 0:
+		s += " (" + strings.Join(w.Places, ", ") + ")"
+	}
+	return s
+}
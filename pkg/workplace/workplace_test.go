@@ -0,0 +1,188 @@
+package workplace
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/justinemmanuelmercado/go-scraper/pkg/models"
+)
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name     string
+		location string
+		title    string
+		body     string
+		kind     Kind
+		regions  []string
+		places   []string
+	}{
+		{
+			name:  "Remote from anywhere",
+			title: "Backend Engineer (Remote, Worldwide)",
+			kind:  RemoteGlobal,
+		},
+		{
+			name:     "Remote in the header of a Hacker News post",
+			location: "Remote (US only)",
+			title:    "Senior Engineer at Acme",
+			kind:     RemoteRegion,
+			regions:  []string{"US"},
+		},
+		{
+			name:    "Region in the body",
+			title:   "[Hiring] React developer",
+			body:    "<p>Fully remote, but you must be based in the EU or the UK.</p>",
+			kind:    RemoteRegion,
+			regions: []string{"EU", "UK"},
+			places:  []string{"United Kingdom"},
+		},
+		{
+			name:    "Timezone band",
+			title:   "Platform Engineer - Remote",
+			body:    "We work UTC-5 to UTC+1 and need overlap with EST hours.",
+			kind:    RemoteRegion,
+			regions: []string{"UTC-5 to UTC+1", "EST"},
+		},
+		{
+			name:    "Offsets without a prefix",
+			title:   "Remote",
+			body:    "Must be within GMT -3 - +2, the company was est. 2015.",
+			kind:    RemoteRegion,
+			regions: []string{"GMT-3 to GMT+2"},
+		},
+		{
+			name:    "Region only",
+			title:   "Data Engineer",
+			body:    "This is a remote role open to LATAM residents.",
+			kind:    RemoteRegion,
+			regions: []string{"LATAM"},
+		},
+		{
+			name:   "Us isn't the US",
+			title:  "Remote Go developer",
+			body:   "Join us from anywhere, we have an office in Berlin.",
+			kind:   RemoteGlobal,
+			places: []string{"Berlin"},
+		},
+		{
+			name:   "Hybrid in the title",
+			title:  "Frontend Engineer (Hybrid, London)",
+			body:   "Remote two days a week.",
+			kind:   Hybrid,
+			places: []string{"London"},
+		},
+		{
+			name:  "Hybrid apps aren't hybrid work",
+			title: "Mobile Developer",
+			body:  "You'll build hybrid apps with Ionic. Fully remote.",
+			kind:  RemoteGlobal,
+		},
+		{
+			name:  "Hybrid in the body",
+			title: "Product Designer",
+			body:  "We have a hybrid work model, 3 days a week in the office.",
+			kind:  Hybrid,
+		},
+		{
+			name:   "On-site",
+			title:  "Embedded Engineer",
+			body:   "This role is on-site in Munich, Germany. Relocation offered.",
+			kind:   Onsite,
+			places: []string{"Germany", "Munich"},
+		},
+		{
+			name:   "Remote that isn't",
+			title:  "SRE (no remote)",
+			body:   "Based in NYC.",
+			kind:   Onsite,
+			places: []string{"New York"},
+		},
+		{
+			name:   "Places without a workplace",
+			title:  "Developer",
+			body:   "Offices in San Francisco, Toronto and São Paulo.",
+			places: []string{"San Francisco", "Toronto", "São Paulo"},
+		},
+		{
+			name:   "Words that are places only in capitals",
+			title:  "Remote Engineer",
+			body:   "We'll roast a turkey and visit austin powers.",
+			kind:   RemoteGlobal,
+			places: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notice := &models.Notice{Title: tc.title, Body: tc.body}
+			if tc.location != "" {
+				notice.Location = &tc.location
+			}
+
+			w := Classify(notice)
+			if w.Kind != tc.kind {
+				t.Errorf("Expected kind %q, got %q", tc.kind, w.Kind)
+			}
+			if !slices.Equal(w.Regions, tc.regions) {
+				t.Errorf("Expected regions %v, got %v", tc.regions, w.Regions)
+			}
+			if !slices.Equal(w.Places, tc.places) {
+				t.Errorf("Expected places %v, got %v", tc.places, w.Places)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	notice := &models.Notice{Title: "Remote (EU) Go developer in Berlin or Lisbon"}
+	Classify(notice).Apply(notice)
+
+	if notice.Workplace == nil || *notice.Workplace != "remote-region" {
+		t.Errorf("Expected remote-region, got %v", notice.Workplace)
+	}
+	if notice.Remote == nil || !*notice.Remote {
+		t.Errorf("Expected remote to be set")
+	}
+	if notice.Regions == nil || *notice.Regions != "EU" || notice.Places == nil || *notice.Places != "Berlin, Lisbon" {
+		t.Errorf("Unexpected regions %v and places %v", notice.Regions, notice.Places)
+	}
+	if w, ok := FromNotice(notice); !ok || w.String() != "Remote (EU)" {
+		t.Errorf("Expected to read back Remote (EU), got %v", w)
+	}
+
+	// The remote flag of the source is kept
+	remote := true
+	notice = &models.Notice{Title: "Engineer", Body: "on-site", Remote: &remote}
+	Classify(notice).Apply(notice)
+	if *notice.Workplace != "onsite" || !*notice.Remote {
+		t.Errorf("Expected the remote flag to be kept, got %v", *notice.Remote)
+	}
+
+	notice = &models.Notice{Title: "Engineer"}
+	Classify(notice).Apply(notice)
+	if notice.Workplace != nil || notice.Remote != nil || notice.Regions != nil || notice.Places != nil {
+		t.Errorf("Expected nothing to be set, got %+v", notice)
+	}
+	if _, ok := FromNotice(notice); ok {
+		t.Errorf("Expected no workplace to read back")
+	}
+}
+
+func TestString(t *testing.T) {
+	testCases := []struct {
+		workplace Workplace
+		expected  string
+	}{
+		{Workplace{Kind: RemoteGlobal, Places: []string{"Berlin"}}, "Remote"},
+		{Workplace{Kind: RemoteRegion, Regions: []string{"US", "UTC-5 to UTC+1"}}, "Remote (US, UTC-5 to UTC+1)"},
+		{Workplace{Kind: Hybrid, Places: []string{"Berlin", "Germany"}}, "Hybrid (Berlin, Germany)"},
+		{Workplace{Kind: Onsite}, "On-site"},
+	}
+
+	for _, tc := range testCases {
+		if s := tc.workplace.String(); s != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, s)
+		}
+	}
+}